@@ -5,11 +5,13 @@ go 1.26.4
 require (
 	github.com/briandowns/openweathermap v0.21.1
 	github.com/dpatrie/urbandictionary v0.0.0-20151214192647-3b38cbf4cb81
+	github.com/fsnotify/fsnotify v1.9.0
 	github.com/gojp/kana v0.1.0
 	github.com/mattermost/mattermost/server/public v0.4.2
 	github.com/pkg/errors v0.9.1
 	github.com/spf13/viper v1.21.0
 	go.uber.org/zap v1.28.0
+	golang.org/x/text v0.37.0
 )
 
 require (
@@ -18,7 +20,6 @@ require (
 	github.com/dyatlov/go-opengraph/opengraph v0.0.0-20220524092352-606d7b1e5f8a // indirect
 	github.com/fatih/color v1.19.0 // indirect
 	github.com/francoispqt/gojay v1.2.13 // indirect
-	github.com/fsnotify/fsnotify v1.9.0 // indirect
 	github.com/go-viper/mapstructure/v2 v2.4.0 // indirect
 	github.com/goccy/go-yaml v1.19.2 // indirect
 	github.com/golang/protobuf v1.5.4 // indirect
@@ -58,7 +59,6 @@ require (
 	golang.org/x/mod v0.36.0 // indirect
 	golang.org/x/net v0.54.0 // indirect
 	golang.org/x/sys v0.44.0 // indirect
-	golang.org/x/text v0.37.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20260511170946-3700d4141b60 // indirect
 	google.golang.org/grpc v1.81.0 // indirect
 	google.golang.org/protobuf v1.36.11 // indirect
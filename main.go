@@ -1,7 +1,10 @@
 package main
 
 import (
+	"context"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/opendwellers/jujubot/pkg/bot"
 	"github.com/opendwellers/jujubot/pkg/config"
@@ -26,6 +29,10 @@ func main() {
 	initLogger()
 	defer func() { _ = logger.Sync() }()
 
+	// Stop on Ctrl+C locally and on SIGTERM from Kubernetes
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Load configuration
 	logger.Sugar().Info("Loading configuration")
 	cfg, err := config.LoadConfig()
@@ -40,10 +47,14 @@ func main() {
 		logger.Sugar().Fatal("Failed to create bot", zap.Error(err))
 	}
 
-	if err := b.Start(); err != nil {
+	if err := b.Start(ctx); err != nil {
 		logger.Sugar().Fatal("Failed to start bot", zap.Error(err))
 	}
 
-	// Block forever
-	select {}
+	// Block until we are asked to stop
+	if err := b.Run(ctx); err != nil {
+		logger.Sugar().Error("Bot did not shut down cleanly", zap.Error(err))
+		return
+	}
+	logger.Sugar().Info("Bot stopped")
 }
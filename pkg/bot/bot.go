@@ -2,34 +2,111 @@ package bot
 
 import (
 	"context"
-	"os"
-	"os/signal"
+	"errors"
+	"net/http"
+	"regexp"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/opendwellers/jujubot/pkg/commands"
 	"github.com/opendwellers/jujubot/pkg/config"
+	"github.com/opendwellers/jujubot/pkg/scheduler"
+	"github.com/opendwellers/jujubot/pkg/store"
 	"go.uber.org/zap"
 )
 
 // Bot represents the Mattermost bot instance
 type Bot struct {
-	config          config.Config
-	client          *model.Client4
-	webSocketClient *model.WebSocketClient
-	user            *model.User
-	team            *model.Team
-	debugChannel    *model.Channel
-	weatherClient   commands.Weather
-	chargeMap       map[string]int
+	config         config.Config
+	client         ChatClient
+	user           *model.User
+	team           *model.Team
+	debugChannel   *model.Channel
+	weatherClient  commands.Weather
+	commands       *Registry
+	reactions      atomic.Pointer[[]patternReaction]
+	mentionPattern *regexp.Regexp
+	store          store.Store
+	dispatcher     *dispatcher
+	users          *userDirectory
+	chatter        *chatter
+	triggers       learnedTriggers
+	limiter        *limiter
+	scheduler      *scheduler.Scheduler
+	clock          Clock
+	random         Random
+	location       *time.Location
+	locations      sync.Map // time zone name -> *time.Location
+	conn           *connection
+	inflight       sync.WaitGroup
+	httpServer     *http.Server
+	authenticated  atomic.Bool
+	teamResolved   atomic.Bool
 }
 
-// New creates a new Bot instance
+// New creates a new Bot instance connected to the configured Mattermost server
+// and persisting its state to the configured store
 func New(cfg config.Config) (*Bot, error) {
+	st := store.NewMemory()
+	if cfg.StorePath == "" {
+		zap.S().Warn("no store path configured, state will be lost on restart")
+	} else {
+		var err error
+		if st, err = store.OpenFile(cfg.StorePath); err != nil {
+			return nil, err
+		}
+	}
+
+	return NewWithClient(cfg, newMattermostClient(cfg.ServerURL, cfg.ServerWSURL, cfg.AuthToken), st)
+}
+
+// NewWithClient creates a new Bot instance using the given chat client and
+// store. Options can replace the clock and the random source, e.g. in tests.
+func NewWithClient(cfg config.Config, client ChatClient, st store.Store, opts ...Option) (*Bot, error) {
 	b := &Bot{
-		config:    cfg,
-		client:    model.NewAPIv4Client(cfg.ServerURL),
-		chargeMap: make(map[string]int),
+		config:   cfg,
+		client:   client,
+		store:    st,
+		conn:     newConnection(),
+		commands: NewRegistry(),
+		clock:    systemClock{},
+		random:   systemRandom{},
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	b.users = newUserDirectory(client, cfg.UserCacheTTL, b.clock.Now)
+	b.limiter = newLimiter(b.clock.Now, b.random.Float64)
+
+	location, err := loadDefaultLocation(cfg.DefaultTimezone)
+	if err != nil {
+		return nil, err
+	}
+	b.location = location
+
+	b.dispatcher = newDispatcher(cfg.EventWorkers, cfg.EventQueueSize, b.handleEvent, b.inflight.Done)
+
+	// Compile every command and reaction pattern once, failing on invalid ones
+	for _, cmd := range defaultCommands() {
+		if err := b.commands.Register(cmd); err != nil {
+			return nil, err
+		}
+	}
+
+	reactions, err := loadReactions(cfg.ReactionsFile)
+	if err != nil {
+		return nil, err
+	}
+	b.reactions.Store(&reactions)
+
+	if err := b.loadTriggers(); err != nil {
+		return nil, err
+	}
+
+	if err := b.loadSchedule(); err != nil {
+		return nil, err
 	}
 
 	// Initialize weather client
@@ -42,70 +119,134 @@ func New(cfg config.Config) (*Bot, error) {
 	return b, nil
 }
 
-// Start initializes the bot and starts listening for events
-func (b *Bot) Start() error {
+// Start logs in and resolves everything the bot needs before it can run
+func (b *Bot) Start(ctx context.Context) error {
+	// Serve health endpoints right away so probes see the bot starting up
+	b.startHTTPServer()
+
+	// Pick up changes to the reactions file without a restart
+	b.watchReactions(ctx)
+
 	zap.S().Info("Connecting to Mattermost at " + b.config.ServerURL)
 
 	// Login
-	if err := b.login(); err != nil {
+	if err := b.login(ctx); err != nil {
 		return err
 	}
 
 	// Find team
-	if err := b.findTeam(); err != nil {
+	if err := b.findTeam(ctx); err != nil {
 		return err
 	}
 
 	// Setup debugging channel
-	b.setupDebuggingChannel()
+	b.setupDebuggingChannel(ctx)
 
-	// Create readiness file
-	if err := b.createReadinessFile(); err != nil {
-		return err
-	}
+	// Load the Markov chain, backfilling it in the background if needed
+	b.startChatter(ctx)
 
-	// Setup graceful shutdown
-	b.setupGracefulShutdown()
+	// Archive the last 4/20 if it ended while the bot was down
+	b.archiveChargeSeason(b.clock.Now())
 
+	// Post the scheduled messages until shutdown
+	b.startScheduler(ctx)
+
+	// Deliver reminders, including those due while the bot was down
+	b.startReminders(ctx)
+
+	return nil
+}
+
+// Run listens for events until ctx is cancelled, then waits for running
+// handlers to finish (up to the configured shutdown timeout)
+func (b *Bot) Run(ctx context.Context) error {
 	zap.S().Info("Bot is now running and listening to messages.")
 
-	// Start WebSocket listener in a goroutine
-	go b.startWebSocketListener()
+	b.dispatcher.start()
+	listenerDone := make(chan struct{})
+	go func() {
+		defer close(listenerDone)
+		b.startWebSocketListener(ctx)
+	}()
+
+	<-ctx.Done()
+	zap.S().Info("Shutting down, waiting for running handlers")
+
+	err := b.drain(listenerDone, b.config.ShutdownTimeout)
+	b.setConnState(stateDisconnected)
+	b.stopHTTPServer()
+	if err != nil {
+		// Handlers still running may write to the store, so it is left open
+		zap.S().Error("Shutdown timed out, leaving the store open", zap.Error(err))
+		return err
+	}
 
+	b.dispatcher.stop()
+	if b.chatter != nil {
+		b.saveChain(b.chatter)
+	}
+	if err := b.store.Close(); err != nil {
+		zap.S().Error("Failed to close store", zap.Error(err))
+	}
 	return nil
 }
 
-// login authenticates the bot with the Mattermost server
-func (b *Bot) login() error {
-	b.client.SetToken(b.config.AuthToken)
+// dispatch hands an event to the worker pool, tracking it as in flight
+func (b *Bot) dispatch(ctx context.Context, event *model.WebSocketEvent) {
+	b.inflight.Add(1)
+	b.dispatcher.dispatch(ctx, event)
+}
+
+// drain waits for the listener and every queued or running handler to return
+func (b *Bot) drain(listenerDone <-chan struct{}, timeout time.Duration) error {
+	done := make(chan struct{})
+	go func() {
+		<-listenerDone
+		b.inflight.Wait()
+		close(done)
+	}()
 
-	user, _, err := b.client.GetMe(context.TODO(), "")
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+		return errors.New("timed out waiting for handlers to finish")
+	}
+}
+
+// login authenticates the bot with the Mattermost server
+func (b *Bot) login(ctx context.Context) error {
+	user, err := b.client.GetMe(ctx)
 	if err != nil {
 		zap.S().Error("Failed to get bot user", zap.Error(err))
 		return err
 	}
 
 	b.user = user
+	b.users.Put(user)
+	b.mentionPattern = regexp.MustCompile(globalRegexOptions + "^@" + regexp.QuoteMeta(user.Username) + " (.*)$")
+	b.authenticated.Store(true)
 	zap.S().Info("Running as " + user.Username)
 	return nil
 }
 
 // findTeam locates the bot's team
-func (b *Bot) findTeam() error {
-	team, _, err := b.client.GetTeamByName(context.TODO(), b.config.TeamName, "")
+func (b *Bot) findTeam(ctx context.Context) error {
+	team, err := b.client.GetTeamByName(ctx, b.config.TeamName)
 	if err != nil {
 		zap.S().Error("Failed to find team '"+b.config.TeamName+"'", zap.Error(err))
 		return err
 	}
 
 	b.team = team
+	b.teamResolved.Store(true)
 	zap.S().Info("Found team " + team.Name)
 	return nil
 }
 
 // setupDebuggingChannel creates or finds the debugging channel
-func (b *Bot) setupDebuggingChannel() {
-	rchannel, _, err := b.client.GetChannelByName(context.TODO(), b.config.ChannelLogName, b.team.Id, "")
+func (b *Bot) setupDebuggingChannel(ctx context.Context) {
+	rchannel, err := b.client.GetChannelByName(ctx, b.config.ChannelLogName, b.team.Id)
 	if err == nil {
 		b.debugChannel = rchannel
 		return
@@ -121,7 +262,7 @@ func (b *Bot) setupDebuggingChannel() {
 		TeamId:      b.team.Id,
 	}
 
-	rchannel, _, err = b.client.CreateChannel(context.TODO(), channel)
+	rchannel, err = b.client.CreateChannel(ctx, channel)
 	if err != nil {
 		zap.S().Error("Failed to create channel "+b.config.ChannelLogName, zap.Error(err))
 		return
@@ -130,63 +271,3 @@ func (b *Bot) setupDebuggingChannel() {
 	b.debugChannel = rchannel
 	zap.S().Info("Created debugging channel " + b.config.ChannelLogName)
 }
-
-// createReadinessFile creates the readiness probe file
-func (b *Bot) createReadinessFile() error {
-	f, err := os.Create("/tmp/ready")
-	if err != nil {
-		zap.S().Error("Failed to create /tmp/ready", zap.Error(err))
-		return err
-	}
-	return f.Close()
-}
-
-// startWebSocketListener manages the WebSocket connection
-func (b *Bot) startWebSocketListener() {
-	for {
-		wsClient, err := model.NewWebSocketClient4(b.config.ServerWSURL, b.client.AuthToken)
-		if err != nil {
-			zap.S().Error("Failed to connect to WebSocket", zap.Error(err))
-			time.Sleep(5 * time.Second)
-			continue
-		}
-
-		b.webSocketClient = wsClient
-		b.listen()
-	}
-}
-
-// listen handles incoming WebSocket events
-func (b *Bot) listen() {
-	b.webSocketClient.Listen()
-	defer b.webSocketClient.Close()
-
-	for {
-		if b.webSocketClient.ListenError != nil {
-			zap.S().Error("WebSocket listen error", zap.Error(b.webSocketClient.ListenError))
-			zap.S().Info("Reconnecting to WebSocket")
-			return
-		}
-
-		event := <-b.webSocketClient.EventChannel
-		if event == nil {
-			continue
-		}
-
-		b.handleEvent(event)
-	}
-}
-
-// setupGracefulShutdown handles OS interrupt signals
-func (b *Bot) setupGracefulShutdown() {
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt)
-	go func() {
-		for range c {
-			if b.webSocketClient != nil {
-				b.webSocketClient.Close()
-			}
-			os.Exit(0)
-		}
-	}()
-}
@@ -0,0 +1,156 @@
+package bot
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/opendwellers/jujubot/pkg/config"
+	"github.com/opendwellers/jujubot/pkg/store"
+)
+
+const testChannelId = "town-square"
+
+// testNow is a plain weekday, far from 4/20
+var testNow = time.Date(2026, time.January, 15, 12, 0, 0, 0, time.UTC)
+
+// newTestBot creates a bot logged in to a FakeClient knowing alice and bob,
+// with an in-memory store. Time is frozen at testNow and random numbers are
+// all 0 unless opts say otherwise.
+func newTestBot(t testing.TB, cfg config.Config, opts ...Option) (*Bot, *FakeClient) {
+	t.Helper()
+	if cfg.DefaultTimezone == "" {
+		cfg.DefaultTimezone = "UTC"
+	}
+
+	client := NewFakeClient(&model.User{Id: "bot", Username: "jujubot"})
+	client.AddUser(&model.User{Id: "alice", Username: "alice"})
+	client.AddUser(&model.User{Id: "bob", Username: "bob"})
+
+	opts = append([]Option{WithClock(NewFakeClock(testNow)), WithRandom(NewScriptedRandom())}, opts...)
+	b, err := NewWithClient(cfg, client, store.NewMemory(), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.login(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return b, client
+}
+
+// say handles a message from userId as if it had just been posted
+func say(b *Bot, userId, message string) *model.Post {
+	post := &model.Post{Id: model.NewId(), ChannelId: testChannelId, UserId: userId, Message: message}
+	b.handleMessage(post)
+	return post
+}
+
+// postedMessages returns the text of everything the bot posted
+func postedMessages(client *FakeClient) []string {
+	var messages []string
+	for _, post := range client.Posts() {
+		messages = append(messages, post.Message)
+	}
+	return messages
+}
+
+// savedReactions returns the emoji of every reaction the bot added
+func savedReactions(client *FakeClient) []string {
+	var emoji []string
+	for _, reaction := range client.Reactions() {
+		emoji = append(emoji, reaction.EmojiName)
+	}
+	return emoji
+}
+
+// closeRecorder is a store that records whether it was closed and whether
+// it was written to afterwards
+type closeRecorder struct {
+	store.Store
+	closed           atomic.Bool
+	writesAfterClose atomic.Int32
+}
+
+func (s *closeRecorder) Update(fn func(tx store.Tx) error) error {
+	if s.closed.Load() {
+		s.writesAfterClose.Add(1)
+	}
+	return s.Store.Update(fn)
+}
+
+func (s *closeRecorder) Close() error {
+	s.closed.Store(true)
+	return s.Store.Close()
+}
+
+// runBlockingBot runs a bot whose "block" command waits for release, and
+// returns once the command started
+func runBlockingBot(t *testing.T, release <-chan struct{}) (*Bot, *closeRecorder, context.CancelFunc, <-chan error) {
+	t.Helper()
+	b, client := newTestBot(t, config.Config{ShutdownTimeout: 50 * time.Millisecond})
+	st := &closeRecorder{Store: b.store}
+	b.store = st
+
+	started := make(chan struct{})
+	err := b.commands.Register(&Command{Name: "block", Handler: func(b *Bot, req *CommandRequest) {
+		close(started)
+		<-release
+		_ = store.Put(b.store, "test", "key", "handled")
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- b.Run(ctx) }()
+	if err := client.EmitPost(&model.Post{Id: model.NewId(), ChannelId: testChannelId, UserId: "alice", Message: "@jujubot block"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler never started")
+	}
+	return b, st, cancel, errc
+}
+
+func TestRunTimeoutLeavesStoreOpen(t *testing.T) {
+	release := make(chan struct{})
+	b, st, cancel, errc := runBlockingBot(t, release)
+
+	cancel()
+	if err := <-errc; err == nil {
+		t.Fatal("Run returned no error though a handler was still running")
+	}
+	if st.closed.Load() {
+		t.Error("store closed while a handler was still running")
+	}
+
+	// The late handler can still save its work
+	close(release)
+	b.inflight.Wait()
+	var got string
+	if _, err := store.Get(st, "test", "key", &got); err != nil || got != "handled" {
+		t.Errorf("got %q, %v, want the handler's write", got, err)
+	}
+}
+
+func TestRunWaitsForHandlers(t *testing.T) {
+	release := make(chan struct{})
+	_, st, cancel, errc := runBlockingBot(t, release)
+
+	cancel()
+	close(release)
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if !st.closed.Load() {
+		t.Error("store not closed")
+	}
+	if n := st.writesAfterClose.Load(); n != 0 {
+		t.Errorf("got %d writes after closing the store", n)
+	}
+}
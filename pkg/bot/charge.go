@@ -1,14 +1,79 @@
 package bot
 
 import (
-	"math/rand"
+	"fmt"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/opendwellers/jujubot/pkg/store"
+	"go.uber.org/zap"
+)
+
+const (
+	// chargeCollection stores the charge points of each user, keyed by user ID
+	chargeCollection = "charge"
+	// chargeEventsCollection logs every change of charge points, keyed by time
+	chargeEventsCollection = "charge_events"
+	// chargeSeasonsCollection stores the final standings of each season, keyed by year
+	chargeSeasonsCollection = "charge_seasons"
+	// chargeMetaCollection stores the last archived season under chargeLastSeasonKey
+	chargeMetaCollection = "charge_meta"
+	chargeLastSeasonKey  = "last_season"
+
+	// chargeTopSize is the number of users shown by charge top
+	chargeTopSize = 10
+	// chargeHistorySize is the number of changes shown by charge history
+	chargeHistorySize = 10
 )
 
+// Reasons of a change of charge points
+const (
+	chargeReasonUp      = "up"
+	chargeReasonGive    = "give"
+	chargeReasonReceive = "receive"
+	chargeReasonBet     = "bet"
+	chargeReasonShop    = "shop"
+)
+
+// chargeEvent is a change of a user's charge points. Together they form the
+// audit trail of the charge economy.
+type chargeEvent struct {
+	UserId string    `json:"user_id"`
+	Delta  int       `json:"delta"`
+	Total  int       `json:"total"`
+	At     time.Time `json:"at"`
+	// Reason is one of the chargeReason constants, up when empty
+	Reason string `json:"reason,omitempty"`
+	// With is the other user of a gift, or the item bought
+	With string `json:"with,omitempty"`
+}
+
+// chargeStanding is the charge of a user in a ranking
+type chargeStanding struct {
+	UserId string `json:"user_id"`
+	Charge int    `json:"charge"`
+}
+
+// is420 tells whether it's 4/20 in the time zone of now
+func is420(now time.Time) bool {
+	return now.Month() == time.April && now.Day() == 20
+}
+
 // chargeUp adds or removes charge points for a user
 func (b *Bot) chargeUp(userId string, multiplier int) string {
-	chargeValue := (rand.Intn(5) - 1) * multiplier
-	b.chargeMap[userId] += chargeValue
+	chargeValue := (b.random.Intn(5) - 1) * multiplier
+	now := b.clock.Now()
+	b.archiveChargeSeason(now)
+	err := b.store.Update(func(tx store.Tx) error {
+		_, err := addCharge(tx, chargeEvent{UserId: userId, Delta: chargeValue, At: now, Reason: chargeReasonUp})
+		return err
+	})
+	if err != nil {
+		zap.S().Error("Failed to save charge", zap.Error(err))
+		return "Couldn't charge up, my battery is dead :pepehands:"
+	}
 
 	switch {
 	case chargeValue < 0:
@@ -22,10 +87,11 @@ func (b *Bot) chargeUp(userId string, multiplier int) string {
 
 // getCharge returns the current charge for a user
 func (b *Bot) getCharge(userId string) int {
-	if val, ok := b.chargeMap[userId]; ok {
-		return val
+	var charge int
+	if _, err := store.Get(b.store, chargeCollection, userId, &charge); err != nil {
+		zap.S().Error("Failed to load charge", zap.Error(err))
 	}
-	return 0
+	return charge
 }
 
 // getChargeLevelMessage returns a message describing the user's charge level
@@ -48,3 +114,188 @@ func (b *Bot) getChargeLevelMessage(userId string) string {
 		return "You have :pogchampignon: points charged up‽"
 	}
 }
+
+// addCharge applies a change of charge points within a transaction and logs
+// it, returning the new total
+func addCharge(tx store.Tx, event chargeEvent) (int, error) {
+	var charge int
+	if _, err := tx.Get(chargeCollection, event.UserId, &charge); err != nil {
+		return 0, err
+	}
+	event.Total = charge + event.Delta
+
+	if err := tx.Put(chargeEventsCollection, chargeEventKey(tx, event.At), event); err != nil {
+		return 0, err
+	}
+	return event.Total, tx.Put(chargeCollection, event.UserId, event.Total)
+}
+
+// chargeEventKey returns a key sorting events by time, unique within tx
+func chargeEventKey(tx store.Tx, at time.Time) string {
+	for n := at.UnixNano(); ; n++ {
+		key := fmt.Sprintf("%020d", n)
+		var event chargeEvent
+		if found, _ := tx.Get(chargeEventsCollection, key, &event); !found {
+			return key
+		}
+	}
+}
+
+// chargeStandings returns every user's charge points, highest first
+func chargeStandings(tx store.Tx) ([]chargeStanding, error) {
+	var standings []chargeStanding
+	for _, userId := range tx.Keys(chargeCollection) {
+		var charge int
+		if _, err := tx.Get(chargeCollection, userId, &charge); err != nil {
+			return nil, err
+		}
+		standings = append(standings, chargeStanding{UserId: userId, Charge: charge})
+	}
+	sort.SliceStable(standings, func(i, j int) bool {
+		return standings[i].Charge > standings[j].Charge
+	})
+	return standings, nil
+}
+
+// archiveChargeSeason closes the season once 4/20 is over in every time
+// zone: starting April 21st, the standings are saved under the year and
+// everybody starts over. The points are also what give, bet and buy spend,
+// so unspent ones are lost, while items bought and flair are kept.
+func (b *Bot) archiveChargeSeason(now time.Time) {
+	now = now.In(lastTimezone)
+	season := now.Year()
+	if now.Before(time.Date(season, time.April, 21, 0, 0, 0, 0, lastTimezone)) {
+		season--
+	}
+
+	err := b.store.Update(func(tx store.Tx) error {
+		var last int
+		found, err := tx.Get(chargeMetaCollection, chargeLastSeasonKey, &last)
+		if err != nil {
+			return err
+		}
+		// On the first run, e.g. after an upgrade, the current standings may
+		// belong to any season so they are kept
+		if !found {
+			return tx.Put(chargeMetaCollection, chargeLastSeasonKey, season)
+		}
+		if last >= season {
+			return nil
+		}
+
+		standings, err := chargeStandings(tx)
+		if err != nil {
+			return err
+		}
+		if len(standings) > 0 {
+			if err := tx.Put(chargeSeasonsCollection, strconv.Itoa(season), standings); err != nil {
+				return err
+			}
+			for _, standing := range standings {
+				if err := tx.Delete(chargeCollection, standing.UserId); err != nil {
+					return err
+				}
+			}
+			zap.S().Info("Archived charge season ", season)
+		}
+		return tx.Put(chargeMetaCollection, chargeLastSeasonKey, season)
+	})
+	if err != nil {
+		zap.S().Error("Failed to archive charge season", zap.Error(err))
+	}
+}
+
+// chargeTopMessage ranks the users with the most charge points
+func (b *Bot) chargeTopMessage() string {
+	var standings []chargeStanding
+	err := b.store.View(func(tx store.Tx) (err error) {
+		standings, err = chargeStandings(tx)
+		return err
+	})
+	if err != nil {
+		zap.S().Error("Failed to load charge", zap.Error(err))
+		return "Couldn't read the charge, my battery is dead :pepehands:"
+	}
+	if len(standings) == 0 {
+		return "Nobody charged up yet :tensepepe:"
+	}
+	return "#### Charge leaderboard\n" + b.chargeTable(standings[:min(chargeTopSize, len(standings))])
+}
+
+// chargeHistoryMessage lists the latest charge changes of a user
+func (b *Bot) chargeHistoryMessage(userId string) string {
+	var events []chargeEvent
+	err := b.store.View(func(tx store.Tx) error {
+		keys := tx.Keys(chargeEventsCollection)
+		for i := len(keys) - 1; i >= 0 && len(events) < chargeHistorySize; i-- {
+			var event chargeEvent
+			if _, err := tx.Get(chargeEventsCollection, keys[i], &event); err != nil {
+				return err
+			}
+			if event.UserId == userId {
+				events = append(events, event)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		zap.S().Error("Failed to load charge history", zap.Error(err))
+		return "Couldn't read the charge, my battery is dead :pepehands:"
+	}
+
+	mention := b.getUserMention(userId)
+	if len(events) == 0 {
+		return mention + " never charged up :tensepepe:"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("#### Charge history of " + mention + "\n")
+	sb.WriteString("| When | Change | Total | Why |\n|:-----|-------:|------:|:----|\n")
+	location := b.userLocation(userId)
+	for _, event := range events {
+		fmt.Fprintf(&sb, "| %s | %+d | %d | %s |\n", event.At.In(location).Format("2006-01-02 15:04"), event.Delta, event.Total, b.chargeReason(event))
+	}
+	return sb.String()
+}
+
+// chargeSeasonMessage shows the final standings of a past season
+func (b *Bot) chargeSeasonMessage(year string) string {
+	var standings []chargeStanding
+	found, err := store.Get(b.store, chargeSeasonsCollection, year, &standings)
+	if err != nil {
+		zap.S().Error("Failed to load charge season", zap.Error(err))
+		return "Couldn't read the charge, my battery is dead :pepehands:"
+	}
+	if !found || len(standings) == 0 {
+		return "No charge season in " + year + " :tensepepe:"
+	}
+
+	return "#### 4/20 " + year + "\n:trophy: " + b.getUserMention(standings[0].UserId) + " was the champion with " +
+		strconv.Itoa(standings[0].Charge) + " points!\n\n" + b.chargeTable(standings[:min(chargeTopSize, len(standings))])
+}
+
+// chargeTable renders ranked standings as a Markdown table
+func (b *Bot) chargeTable(standings []chargeStanding) string {
+	var sb strings.Builder
+	sb.WriteString("| # | User | Points |\n|--:|:-----|-------:|\n")
+	for i, standing := range standings {
+		fmt.Fprintf(&sb, "| %d | %s | %d |\n", i+1, b.getUserMention(standing.UserId), standing.Charge)
+	}
+	return sb.String()
+}
+
+// chargeReason describes why a charge event happened
+func (b *Bot) chargeReason(event chargeEvent) string {
+	switch event.Reason {
+	case chargeReasonGive:
+		return "gift to " + b.getUserMention(event.With)
+	case chargeReasonReceive:
+		return "gift from " + b.getUserMention(event.With)
+	case chargeReasonBet:
+		return "bet"
+	case chargeReasonShop:
+		return "bought " + event.With
+	default:
+		return "charged up"
+	}
+}
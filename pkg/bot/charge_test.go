@@ -0,0 +1,129 @@
+package bot
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/opendwellers/jujubot/pkg/config"
+	"github.com/opendwellers/jujubot/pkg/store"
+)
+
+func TestArchiveChargeSeason(t *testing.T) {
+	balances := map[string]int{"alice": 42, "bob": 7}
+	tests := []struct {
+		name string
+		// lastSeason is the stored last archived season, none when 0
+		lastSeason  int
+		now         time.Time
+		wantSeason  int
+		wantArchive bool
+	}{
+		{"first run keeps the balances", 0, time.Date(2026, time.June, 1, 12, 0, 0, 0, time.UTC), 2026, false},
+		{"first run before 4/20", 0, time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC), 2025, false},
+		{"season over", 2025, time.Date(2026, time.April, 22, 12, 0, 0, 0, time.UTC), 2026, true},
+		{"several seasons missed", 2023, time.Date(2026, time.June, 1, 12, 0, 0, 0, time.UTC), 2026, true},
+		{"4/20 not over everywhere", 2025, time.Date(2026, time.April, 21, 6, 0, 0, 0, time.UTC), 2025, false},
+		{"already archived", 2026, time.Date(2026, time.June, 1, 12, 0, 0, 0, time.UTC), 2026, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := newTestBot(t, config.Config{})
+			err := b.store.Update(func(tx store.Tx) error {
+				for userId, charge := range balances {
+					if err := tx.Put(chargeCollection, userId, charge); err != nil {
+						return err
+					}
+				}
+				if tt.lastSeason == 0 {
+					return nil
+				}
+				return tx.Put(chargeMetaCollection, chargeLastSeasonKey, tt.lastSeason)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			b.archiveChargeSeason(tt.now)
+
+			var season int
+			var archived []chargeStanding
+			var kept []string
+			_ = b.store.View(func(tx store.Tx) error {
+				_, _ = tx.Get(chargeMetaCollection, chargeLastSeasonKey, &season)
+				_, _ = tx.Get(chargeSeasonsCollection, strconv.Itoa(tt.wantSeason), &archived)
+				kept = tx.Keys(chargeCollection)
+				return nil
+			})
+			if season != tt.wantSeason {
+				t.Errorf("last season is %d, want %d", season, tt.wantSeason)
+			}
+			if tt.wantArchive {
+				want := []chargeStanding{{UserId: "alice", Charge: 42}, {UserId: "bob", Charge: 7}}
+				if !slices.Equal(archived, want) || len(kept) != 0 {
+					t.Errorf("archived %v and kept %q, want %v archived and nothing kept", archived, kept, want)
+				}
+			} else if len(archived) != 0 || len(kept) != len(balances) {
+				t.Errorf("archived %v and kept %q, want every balance kept", archived, kept)
+			}
+		})
+	}
+}
+
+func TestChargeUp(t *testing.T) {
+	tests := []struct {
+		multiplier int
+		draw       int64
+		wantDelta  int
+		want       string
+	}{
+		{1, 0, -1, "You lost 1 charge points :lamo:"},
+		{1, 1, 0, "You gained 0 charge points :pepehands:"},
+		{1, 2, 1, "You gained 1 charge points :hype:"},
+		{1, 3, 2, "You gained 2 charge points :hype:"},
+		{1, 4, 3, "You gained 3 charge points :hype:"},
+		{2, 0, -2, "You lost 2 charge points :lamo:"},
+		{2, 1, 0, "You gained 0 charge points :pepehands:"},
+		{2, 4, 6, "You gained 6 charge points :hype:"},
+		{5, 0, -5, "You lost 5 charge points :lamo:"},
+		{5, 3, 10, "You gained 10 charge points :hype:"},
+		{5, 4, 15, "You gained 15 charge points :hype:"},
+	}
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.multiplier)+"x"+strconv.Itoa(int(tt.draw)), func(t *testing.T) {
+			b, _ := newTestBot(t, config.Config{}, WithRandom(NewScriptedRandom(tt.draw)))
+			if err := store.Put(b.store, chargeCollection, "alice", 10); err != nil {
+				t.Fatal(err)
+			}
+			if got := b.chargeUp("alice", tt.multiplier); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if got := b.getCharge("alice"); got != 10+tt.wantDelta {
+				t.Errorf("charge is %d, want %d", got, 10+tt.wantDelta)
+			}
+		})
+	}
+}
+
+func TestChargingUpMultiplier(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		// Always the highest draw, 3 points per multiplier
+		{"aaaaahh", "@alice: You gained 3 charge points :hype:"},
+		{"aaaaaaaaaaaaaaaaaaaaahh", "@alice: You gained 6 charge points :hype:"},
+		{"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaahh", "@alice: You gained 9 charge points :hype:"},
+		{"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaahh!!!", "@alice: You gained 9 charge points :hype:"},
+	}
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(len(tt.message)), func(t *testing.T) {
+			b, client := newTestBot(t, config.Config{}, WithRandom(NewScriptedRandom(4)))
+			say(b, "alice", tt.message)
+			if got := postedMessages(client); !slices.Equal(got, []string{tt.want}) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
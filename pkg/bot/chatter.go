@@ -0,0 +1,305 @@
+package bot
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/opendwellers/jujubot/pkg/markov"
+	"github.com/opendwellers/jujubot/pkg/store"
+	"go.uber.org/zap"
+)
+
+const (
+	// markovCollection stores the chain, keyed by state
+	markovCollection = "markov"
+	// markovMetaCollection stores what the chain was trained on under markovMetaKey
+	markovMetaCollection = "markov_meta"
+	markovMetaKey        = "meta"
+
+	// defaultMaxWords caps generated messages when max_words isn't set
+	defaultMaxWords = 30
+
+	// backfillPageSize is the number of posts fetched per request when backfilling
+	backfillPageSize = 200
+
+	// markovSaveInterval is how often learned states are written to the
+	// store. Writing on every post would rewrite the whole store each time.
+	markovSaveInterval = time.Minute
+)
+
+// chatter imitates a user with a Markov chain trained on their messages
+type chatter struct {
+	chain  *markov.Chain
+	userId string
+
+	// rand.Rand isn't safe for concurrent use
+	mu  sync.Mutex
+	rng *rand.Rand
+
+	// dirty holds the states changed since the chain was last saved
+	dirtyMu sync.Mutex
+	dirty   map[string]struct{}
+}
+
+// markovMeta records what the stored chain was trained on, so it is rebuilt
+// when the user or the order changes
+type markovMeta struct {
+	UserId     string `json:"user_id"`
+	Order      int    `json:"order"`
+	Backfilled bool   `json:"backfilled"`
+}
+
+// generate returns a new message, or an empty string if nothing was learned yet
+func (c *chatter) generate(maxWords int) string {
+	if maxWords <= 0 {
+		maxWords = defaultMaxWords
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.chain.Generate(c.rng, maxWords)
+}
+
+// markDirty records states to save with the next saveChain
+func (c *chatter) markDirty(states []string) {
+	c.dirtyMu.Lock()
+	defer c.dirtyMu.Unlock()
+	if c.dirty == nil {
+		c.dirty = make(map[string]struct{})
+	}
+	for _, state := range states {
+		c.dirty[state] = struct{}{}
+	}
+}
+
+// takeDirty returns the states changed since the last call, sorted
+func (c *chatter) takeDirty() []string {
+	c.dirtyMu.Lock()
+	defer c.dirtyMu.Unlock()
+	states := make([]string, 0, len(c.dirty))
+	for state := range c.dirty {
+		states = append(states, state)
+	}
+	c.dirty = nil
+	sort.Strings(states)
+	return states
+}
+
+// chance reports true with the given probability
+func (c *chatter) chance(probability float64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rng.Float64() < probability
+}
+
+// startChatter loads the chain of the configured user and backfills it from
+// the channel history in the background the first time
+func (b *Bot) startChatter(ctx context.Context) {
+	cfg := b.config.Markov
+	if cfg.User == "" {
+		return
+	}
+
+	user, err := b.users.GetByUsername(ctx, cfg.User)
+	if err != nil {
+		zap.S().Error("Failed to find Markov user "+cfg.User+", chatter disabled", zap.Error(err))
+		return
+	}
+
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = b.random.Int63n(math.MaxInt64)
+	}
+	c := &chatter{
+		chain:  markov.New(cfg.Order),
+		userId: user.Id,
+		rng:    rand.New(rand.NewSource(seed)),
+	}
+
+	meta, err := b.loadChain(c)
+	if err != nil {
+		zap.S().Error("Failed to load Markov chain, chatter disabled", zap.Error(err))
+		return
+	}
+	b.chatter = c
+	zap.S().Info("Loaded Markov chain for ", cfg.User, " with ", c.chain.Len(), " states")
+
+	// Learned states are saved in batches, and once more on shutdown by Run
+	b.inflight.Add(1)
+	go func() {
+		defer b.inflight.Done()
+		ticker := time.NewTicker(markovSaveInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				b.saveChain(c)
+			}
+		}
+	}()
+
+	if !meta.Backfilled {
+		// Tracked as in flight so shutdown doesn't close the store under it
+		b.inflight.Add(1)
+		go func() {
+			defer b.inflight.Done()
+			b.backfillChain(ctx, c, b.clock.Now().UnixMilli())
+		}()
+	}
+}
+
+// loadChain reads the stored chain into c, clearing it if it was trained for
+// another user or order
+func (b *Bot) loadChain(c *chatter) (markovMeta, error) {
+	var meta markovMeta
+	err := b.store.Update(func(tx store.Tx) error {
+		if _, err := tx.Get(markovMetaCollection, markovMetaKey, &meta); err != nil {
+			return err
+		}
+
+		if meta.UserId != c.userId || meta.Order != c.chain.Order() {
+			for _, state := range tx.Keys(markovCollection) {
+				if err := tx.Delete(markovCollection, state); err != nil {
+					return err
+				}
+			}
+			meta = markovMeta{UserId: c.userId, Order: c.chain.Order()}
+			return tx.Put(markovMetaCollection, markovMetaKey, meta)
+		}
+
+		for _, state := range tx.Keys(markovCollection) {
+			var next map[string]int
+			if _, err := tx.Get(markovCollection, state, &next); err != nil {
+				return err
+			}
+			c.chain.SetState(state, next)
+		}
+		return nil
+	})
+	return meta, err
+}
+
+// backfillChain trains the chain on the user's posts older than before, then
+// marks the chain as backfilled
+func (b *Bot) backfillChain(ctx context.Context, c *chatter, before int64) {
+	channelIds := b.config.Markov.Channels
+	if len(channelIds) == 0 {
+		channels, err := b.client.GetChannelsForUser(ctx, b.team.Id, b.user.Id)
+		if err != nil {
+			zap.S().Error("Failed to list channels for Markov backfill", zap.Error(err))
+			return
+		}
+		for _, channel := range channels {
+			channelIds = append(channelIds, channel.Id)
+		}
+	}
+
+	zap.S().Info("Backfilling Markov chain from ", len(channelIds), " channels")
+	for _, channelId := range channelIds {
+		for page := 0; page*backfillPageSize < b.config.Markov.BackfillLimit; page++ {
+			if ctx.Err() != nil {
+				return
+			}
+
+			posts, err := b.client.GetPostsForChannel(ctx, channelId, page, backfillPageSize)
+			if err != nil {
+				zap.S().Error("Failed to get posts for Markov backfill", zap.String("channel", channelId), zap.Error(err))
+				break
+			}
+
+			for _, post := range posts {
+				// Newer posts are learned live
+				if post.CreateAt < before && b.shouldLearn(c, post) {
+					c.markDirty(c.chain.Train(post.Message))
+				}
+			}
+
+			if len(posts) < backfillPageSize {
+				break
+			}
+		}
+	}
+
+	b.saveChain(c)
+	err := b.store.Update(func(tx store.Tx) error {
+		return tx.Put(markovMetaCollection, markovMetaKey, markovMeta{UserId: c.userId, Order: c.chain.Order(), Backfilled: true})
+	})
+	if err != nil {
+		zap.S().Error("Failed to save Markov backfill state", zap.Error(err))
+		return
+	}
+	zap.S().Info("Markov backfill done, chain has ", c.chain.Len(), " states")
+}
+
+// learn trains the chain on a new post of the imitated user. The changes are
+// saved later by saveChain.
+func (b *Bot) learn(post *model.Post) {
+	c := b.chatter
+	if c == nil || !b.shouldLearn(c, post) {
+		return
+	}
+	c.markDirty(c.chain.Train(post.Message))
+}
+
+// shouldLearn skips posts from other users, system messages and commands
+func (b *Bot) shouldLearn(c *chatter, post *model.Post) bool {
+	return post.UserId == c.userId && post.Type == "" && !b.mentionPattern.MatchString(post.Message)
+}
+
+// saveChain persists the states of the chain changed since it was last
+// saved, in a single transaction
+func (b *Bot) saveChain(c *chatter) {
+	states := c.takeDirty()
+	if len(states) == 0 {
+		return
+	}
+	err := b.store.Update(func(tx store.Tx) error {
+		for _, state := range states {
+			if err := tx.Put(markovCollection, state, c.chain.State(state)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		// Try again next time
+		c.markDirty(states)
+		zap.S().Error("Failed to save Markov chain", zap.Error(err))
+	}
+}
+
+// interject sometimes chimes in with a generated message
+func (b *Bot) interject(post *model.Post, replyToId string) bool {
+	c := b.chatter
+	if c == nil || b.config.Markov.InterjectionChance <= 0 || !c.chance(b.config.Markov.InterjectionChance) {
+		return false
+	}
+
+	message := c.generate(b.config.Markov.MaxWords)
+	if message == "" {
+		return false
+	}
+	b.createPost(post.ChannelId, message, replyToId)
+	return true
+}
+
+// handleHuelCommand says something the way the imitated user would
+func (b *Bot) handleHuelCommand(req *CommandRequest) {
+	post := req.Post
+	if b.chatter == nil {
+		b.createPost(post.ChannelId, "chu pas configure pour ca :huel:", req.ReplyToId)
+		return
+	}
+
+	message := b.chatter.generate(b.config.Markov.MaxWords)
+	if message == "" {
+		message = "..."
+	}
+	b.createPost(post.ChannelId, message, req.ReplyToId)
+}
@@ -0,0 +1,110 @@
+package bot
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/opendwellers/jujubot/pkg/config"
+	"github.com/opendwellers/jujubot/pkg/store"
+)
+
+// newChatterBot creates a bot imitating alice with a fixed seed. The chain
+// is marked as backfilled so no history is read.
+func newChatterBot(t *testing.T) (*Bot, *FakeClient) {
+	t.Helper()
+	b, client := newTestBot(t, config.Config{Markov: config.MarkovConfig{User: "alice", Order: 2, Seed: 42, MaxWords: 30}})
+	err := b.store.Update(func(tx store.Tx) error {
+		return tx.Put(markovMetaCollection, markovMetaKey, markovMeta{UserId: "alice", Order: 2, Backfilled: true})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(func() {
+		cancel()
+		b.inflight.Wait()
+	})
+	b.startChatter(ctx)
+	if b.chatter == nil {
+		t.Fatal("chatter not started")
+	}
+	return b, client
+}
+
+// learnFrom trains the chatter on a message as if userId had just posted it
+func learnFrom(b *Bot, userId, message string) {
+	b.learn(&model.Post{Id: model.NewId(), ChannelId: testChannelId, UserId: userId, Message: message})
+}
+
+var aliceMessages = []string{
+	"le velo en hiver cest dangereux",
+	"le velo cest la vie",
+	"en hiver je prends le bus",
+	"la vie est belle en hiver",
+}
+
+func TestHuelCommand(t *testing.T) {
+	var outputs [][]string
+	for range 2 {
+		b, client := newChatterBot(t)
+		for _, message := range aliceMessages {
+			learnFrom(b, "alice", message)
+		}
+		for range 5 {
+			say(b, "bob", "@jujubot huel")
+		}
+		outputs = append(outputs, postedMessages(client))
+	}
+
+	if len(outputs[0]) != 5 {
+		t.Fatalf("got %q, want 5 messages", outputs[0])
+	}
+	if !slices.Equal(outputs[0], outputs[1]) {
+		t.Errorf("same seed gave %q then %q", outputs[0], outputs[1])
+	}
+	for _, message := range outputs[0] {
+		if message == "" || message == "..." {
+			t.Errorf("got %q, want a generated message", message)
+		}
+	}
+}
+
+func TestHuelCommandNotConfigured(t *testing.T) {
+	b, client := newTestBot(t, config.Config{})
+	say(b, "bob", "@jujubot huel")
+	want := []string{"chu pas configure pour ca :huel:"}
+	if got := postedMessages(client); !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestLearnBatchesWrites(t *testing.T) {
+	b, _ := newChatterBot(t)
+	learnFrom(b, "alice", "a la prochaine")
+	learnFrom(b, "bob", "bob isn't imitated")
+	learnFrom(b, "alice", "@jujubot mentions aren't learned")
+
+	storedStates := func() []string {
+		var keys []string
+		_ = b.store.View(func(tx store.Tx) error {
+			keys = tx.Keys(markovCollection)
+			return nil
+		})
+		return keys
+	}
+	if keys := storedStates(); len(keys) != 0 {
+		t.Fatalf("states saved before saveChain: %q", keys)
+	}
+
+	b.saveChain(b.chatter)
+	if keys := storedStates(); len(keys) != 4 {
+		t.Fatalf("got %d saved states, want 4", len(keys))
+	}
+	if dirty := b.chatter.takeDirty(); len(dirty) != 0 {
+		t.Errorf("states still dirty after saving: %q", dirty)
+	}
+
+}
@@ -0,0 +1,194 @@
+package bot
+
+import (
+	"context"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"go.uber.org/zap"
+)
+
+// ChatClient is the subset of the Mattermost API used by the bot
+type ChatClient interface {
+	GetMe(ctx context.Context) (*model.User, error)
+	GetUser(ctx context.Context, userId string) (*model.User, error)
+	GetUserByUsername(ctx context.Context, username string) (*model.User, error)
+	GetTeamByName(ctx context.Context, name string) (*model.Team, error)
+	GetChannelByName(ctx context.Context, name, teamId string) (*model.Channel, error)
+	CreateChannel(ctx context.Context, channel *model.Channel) (*model.Channel, error)
+	// CreateDirectChannel returns the direct message channel of two users,
+	// creating it if needed
+	CreateDirectChannel(ctx context.Context, userId1, userId2 string) (*model.Channel, error)
+	CreatePost(ctx context.Context, post *model.Post) (*model.Post, error)
+	SaveReaction(ctx context.Context, reaction *model.Reaction) (*model.Reaction, error)
+	GetChannelsForUser(ctx context.Context, teamId, userId string) ([]*model.Channel, error)
+	GetPostsSince(ctx context.Context, channelId string, since int64) ([]*model.Post, error)
+	// GetPostsForChannel returns a page of a channel's posts, newest first
+	GetPostsForChannel(ctx context.Context, channelId string, page, perPage int) ([]*model.Post, error)
+	Connect() (EventStream, error)
+}
+
+// EventStream is a live stream of WebSocket events
+type EventStream interface {
+	// Events returns the channel on which events are delivered. It is closed
+	// when the underlying connection goes away.
+	Events() <-chan *model.WebSocketEvent
+	// Ping asks the server to answer with a pong
+	Ping()
+	// Pongs receives a value every time the server answers a Ping
+	Pongs() <-chan struct{}
+	// Err returns the error that ended the stream, if any
+	Err() error
+	Close()
+}
+
+// mattermostClient implements ChatClient against a real Mattermost server
+type mattermostClient struct {
+	client *model.Client4
+	wsURL  string
+}
+
+// newMattermostClient creates a ChatClient authenticated with the given token
+func newMattermostClient(serverURL, wsURL, authToken string) *mattermostClient {
+	client := model.NewAPIv4Client(serverURL)
+	client.SetToken(authToken)
+	return &mattermostClient{
+		client: client,
+		wsURL:  wsURL,
+	}
+}
+
+func (c *mattermostClient) GetMe(ctx context.Context) (*model.User, error) {
+	user, _, err := c.client.GetMe(ctx, "")
+	return user, err
+}
+
+func (c *mattermostClient) GetUser(ctx context.Context, userId string) (*model.User, error) {
+	user, _, err := c.client.GetUser(ctx, userId, "")
+	return user, err
+}
+
+func (c *mattermostClient) GetUserByUsername(ctx context.Context, username string) (*model.User, error) {
+	user, _, err := c.client.GetUserByUsername(ctx, username, "")
+	return user, err
+}
+
+func (c *mattermostClient) GetTeamByName(ctx context.Context, name string) (*model.Team, error) {
+	team, _, err := c.client.GetTeamByName(ctx, name, "")
+	return team, err
+}
+
+func (c *mattermostClient) GetChannelByName(ctx context.Context, name, teamId string) (*model.Channel, error) {
+	channel, _, err := c.client.GetChannelByName(ctx, name, teamId, "")
+	return channel, err
+}
+
+func (c *mattermostClient) CreateChannel(ctx context.Context, channel *model.Channel) (*model.Channel, error) {
+	created, _, err := c.client.CreateChannel(ctx, channel)
+	return created, err
+}
+
+func (c *mattermostClient) CreateDirectChannel(ctx context.Context, userId1, userId2 string) (*model.Channel, error) {
+	channel, _, err := c.client.CreateDirectChannel(ctx, userId1, userId2)
+	return channel, err
+}
+
+func (c *mattermostClient) CreatePost(ctx context.Context, post *model.Post) (*model.Post, error) {
+	created, _, err := c.client.CreatePost(ctx, post)
+	return created, err
+}
+
+func (c *mattermostClient) SaveReaction(ctx context.Context, reaction *model.Reaction) (*model.Reaction, error) {
+	saved, _, err := c.client.SaveReaction(ctx, reaction)
+	return saved, err
+}
+
+func (c *mattermostClient) GetChannelsForUser(ctx context.Context, teamId, userId string) ([]*model.Channel, error) {
+	channels, _, err := c.client.GetChannelsForTeamForUser(ctx, teamId, userId, false, "")
+	return channels, err
+}
+
+func (c *mattermostClient) GetPostsSince(ctx context.Context, channelId string, since int64) ([]*model.Post, error) {
+	list, _, err := c.client.GetPostsSince(ctx, channelId, since, false)
+	if err != nil {
+		return nil, err
+	}
+	return list.ToSlice(), nil
+}
+
+func (c *mattermostClient) GetPostsForChannel(ctx context.Context, channelId string, page, perPage int) ([]*model.Post, error) {
+	list, _, err := c.client.GetPostsForChannel(ctx, channelId, page, perPage, "", false, false)
+	if err != nil {
+		return nil, err
+	}
+	return list.ToSlice(), nil
+}
+
+// Connect opens a new WebSocket connection and starts listening on it
+func (c *mattermostClient) Connect() (EventStream, error) {
+	wsClient, err := model.NewWebSocketClient4(c.wsURL, c.client.AuthToken)
+	if err != nil {
+		return nil, err
+	}
+	wsClient.Listen()
+
+	s := &mattermostStream{
+		wsClient: wsClient,
+		pongs:    make(chan struct{}, 1),
+	}
+	go s.watch()
+	return s, nil
+}
+
+// mattermostStream adapts a model.WebSocketClient to EventStream
+type mattermostStream struct {
+	wsClient *model.WebSocketClient
+	pongs    chan struct{}
+}
+
+// watch drains the response and ping timeout channels, which the client
+// requires to avoid deadlocks. A server-side ping timeout closes the stream.
+func (s *mattermostStream) watch() {
+	for {
+		select {
+		case resp, ok := <-s.wsClient.ResponseChannel:
+			if !ok {
+				return
+			}
+			if text, _ := resp.Data["text"].(string); text == "pong" {
+				select {
+				case s.pongs <- struct{}{}:
+				default:
+				}
+			}
+		case <-s.wsClient.PingTimeoutChannel:
+			zap.S().Warn("No ping received from server, closing WebSocket")
+			s.wsClient.Close()
+		}
+	}
+}
+
+func (s *mattermostStream) Events() <-chan *model.WebSocketEvent {
+	return s.wsClient.EventChannel
+}
+
+func (s *mattermostStream) Ping() {
+	// SendMessage panics if the connection was closed underneath us, in which
+	// case the closed event channel will trigger a reconnect anyway
+	defer func() { _ = recover() }()
+	s.wsClient.SendMessage("ping", nil)
+}
+
+func (s *mattermostStream) Pongs() <-chan struct{} {
+	return s.pongs
+}
+
+func (s *mattermostStream) Err() error {
+	if s.wsClient.ListenError != nil {
+		return s.wsClient.ListenError
+	}
+	return nil
+}
+
+func (s *mattermostStream) Close() {
+	s.wsClient.Close()
+}
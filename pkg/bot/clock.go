@@ -0,0 +1,39 @@
+package bot
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Clock tells the bot what time it is
+type Clock interface {
+	Now() time.Time
+}
+
+// Random is the bot's source of random numbers. Implementations must be safe
+// for concurrent use.
+type Random interface {
+	// Intn returns a number in [0, n)
+	Intn(n int) int
+	// Int63n returns a number in [0, n)
+	Int63n(n int64) int64
+	// Float64 returns a number in [0, 1)
+	Float64() float64
+}
+
+// Option customizes a Bot created with NewWithClient
+type Option func(b *Bot)
+
+// systemClock is the wall clock
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// systemRandom uses the global math/rand source
+type systemRandom struct{}
+
+func (systemRandom) Intn(n int) int       { return rand.Intn(n) }
+func (systemRandom) Int63n(n int64) int64 { return rand.Int63n(n) }
+func (systemRandom) Float64() float64     { return rand.Float64() }
@@ -0,0 +1,135 @@
+package bot
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// WithClock makes the bot read the time from clock
+func WithClock(clock Clock) Option {
+	return func(b *Bot) {
+		b.clock = clock
+	}
+}
+
+// WithRandom makes the bot draw random numbers from random
+func WithRandom(random Random) Option {
+	return func(b *Bot) {
+		b.random = random
+	}
+}
+
+// FakeClock is a Clock that only moves when told to
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFakeClock creates a FakeClock set to now
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Set moves the clock to now
+func (c *FakeClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+// Advance moves the clock forward by d
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// SeededRandom is a Random giving the same sequence for the same seed
+type SeededRandom struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// NewSeededRandom creates a SeededRandom from seed
+func NewSeededRandom(seed int64) *SeededRandom {
+	return &SeededRandom{rng: rand.New(rand.NewSource(seed))}
+}
+
+func (r *SeededRandom) Intn(n int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rng.Intn(n)
+}
+
+func (r *SeededRandom) Int63n(n int64) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rng.Int63n(n)
+}
+
+func (r *SeededRandom) Float64() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rng.Float64()
+}
+
+// ScriptedRandom is a Random returning preset values, to force a given roll
+// or choice. Intn and Int63n return the next int modulo n, Float64 the next
+// float, and both return 0 once their values run out.
+type ScriptedRandom struct {
+	mu     sync.Mutex
+	ints   []int64
+	floats []float64
+}
+
+// NewScriptedRandom creates a ScriptedRandom returning ints in order
+func NewScriptedRandom(ints ...int64) *ScriptedRandom {
+	return &ScriptedRandom{ints: ints}
+}
+
+// PushInts queues more values for Intn and Int63n
+func (r *ScriptedRandom) PushInts(ints ...int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ints = append(r.ints, ints...)
+}
+
+// PushFloats queues values for Float64
+func (r *ScriptedRandom) PushFloats(floats ...float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.floats = append(r.floats, floats...)
+}
+
+func (r *ScriptedRandom) Intn(n int) int {
+	return int(r.Int63n(int64(n)))
+}
+
+func (r *ScriptedRandom) Int63n(n int64) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return next(&r.ints) % n
+}
+
+func (r *ScriptedRandom) Float64() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return next(&r.floats)
+}
+
+// next pops the first value
+func next[T int64 | float64](values *[]T) T {
+	var v T
+	if len(*values) > 0 {
+		v = (*values)[0]
+		*values = (*values)[1:]
+	}
+	return v
+}
@@ -1,174 +1,389 @@
 package bot
 
 import (
+	"context"
 	"fmt"
-	"math/rand"
-	"regexp"
+	"slices"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/gojp/kana"
 	"github.com/mattermost/mattermost/server/public/model"
 	"github.com/opendwellers/jujubot/pkg/commands"
 )
 
+// defaultCommands returns the built-in named commands
+func defaultCommands() []*Command {
+	return []*Command{
+		{
+			Name:        "stfu",
+			Aliases:     []string{"fuck you", "fuck off", "ta yeule", "tayeule", "shut up", "shut the fuck up"},
+			Description: "Tell the bot to shut up",
+			Usage:       "stfu",
+			Args:        `.*`,
+			Handler:     (*Bot).handleInsultCommand,
+		},
+		{
+			Name:        "thanks",
+			Aliases:     []string{"merci", "ty", "thx"},
+			Description: "Thank the bot",
+			Usage:       "thanks",
+			Args:        `.*`,
+			Handler:     (*Bot).handleThanksCommand,
+		},
+		{
+			Name:        "est-ce",
+			Description: "Ask the bot a yes or no question",
+			Usage:       "est-ce que <question>",
+			Examples:    []string{"est-ce que huel va venir?"},
+			Args:        `qu.*`,
+			Handler:     (*Bot).handleQuestionCommand,
+		},
+		{
+			Name:        "I love you",
+			Description: "Declare your love to the bot",
+			Usage:       "I love you",
+			Handler:     (*Bot).handleLoveCommand,
+		},
+		{
+			Name:        "charge",
+			Description: "Charge up your points and check your level on 4/20, spend them, or look at the standings. Points reset after 4/20 but items bought are kept",
+			Usage:       "charge up|level|top|history [@user]|season <year>|give @user <points>|bet <points>|shop|buy <item>",
+			Examples:    []string{"charge up", "charge level", "charge top", "charge history @huel", "charge season 2025", "charge give @huel 5", "charge bet 10", "charge shop", "charge buy crown"},
+			Args:        `(\S+)(?: (.*))?`,
+			Handler:     (*Bot).handleChargeCommand,
+		},
+		{
+			Name:        "convert",
+			Description: "Convert between currencies",
+			Usage:       "convert [amount] <from> [to] <to>",
+			Examples:    []string{"convert", "convert 100 usd to cad", "convert eur jpy"},
+			Args:        `(?:(\d+)? ?(\w{3}) (?:to )?(\w{3}))?`,
+			Handler:     (*Bot).handleConvertCommand,
+		},
+		{
+			Name:        "weather",
+			Description: "Show the forecast, or the current weather with now",
+			Usage:       "weather [now] [location]",
+			Examples:    []string{"weather", "weather now", "weather Quebec"},
+			Args:        `(?:(now)(?: (.*))?|(.*))`,
+			Handler:     (*Bot).handleWeatherCommand,
+		},
+		{
+			Name:        "urban",
+			Description: "Look a word up on Urban Dictionary",
+			Usage:       "urban [word]",
+			Examples:    []string{"urban huel"},
+			Args:        `(.*)`,
+			Handler:     (*Bot).handleUrbanCommand,
+		},
+		{
+			Name:        "romaji",
+			Description: "Convert kana to romaji",
+			Usage:       "romaji <kana>",
+			Examples:    []string{"romaji ひらがな"},
+			Args:        `(.*)`,
+			Handler:     (*Bot).handleRomajiCommand,
+		},
+		{
+			Name:        "hiragana",
+			Description: "Convert romaji to hiragana",
+			Usage:       "hiragana <romaji>",
+			Examples:    []string{"hiragana konnichiwa"},
+			Args:        `(.*)`,
+			Handler:     (*Bot).handleHiraganaCommand,
+		},
+		{
+			Name:        "katakana",
+			Description: "Convert romaji to katakana",
+			Usage:       "katakana <romaji>",
+			Examples:    []string{"katakana konpyuuta"},
+			Args:        `(.*)`,
+			Handler:     (*Bot).handleKatakanaCommand,
+		},
+		{
+			Name:        "wotd",
+			Description: "Show the Japanese word of the day",
+			Usage:       "wotd japanese",
+			Examples:    []string{"wotd japanese"},
+			Args:        `japanese.*`,
+			Handler:     (*Bot).handleWotdCommand,
+		},
+		{
+			Name:        "mmr",
+			Description: "Show the Dota 2 MMR of a player",
+			Usage:       "mmr [player id]",
+			Examples:    []string{"mmr", "mmr 53515020"},
+			Args:        `(\d+)?`,
+			Handler:     (*Bot).handleDotaCommand,
+		},
+		{
+			Name:        "roll",
+			Description: "Roll dice, or try your luck at 4:20",
+			Usage:       "roll [sides|:weed:|dice notation]",
+			Examples:    []string{"roll", "roll 20", "roll :weed:", "roll 2d6+3", "roll 4d6kh3", "roll d20 adv", "roll 3d6!"},
+			Args:        `(.*?)\s*`,
+			Handler:     (*Bot).handleRollCommand,
+		},
+		{
+			Name:        "when someone says",
+			Aliases:     []string{"quand quelqu'un dit"},
+			Description: "Teach the bot to answer something, with a regex between slashes",
+			Usage:       "when someone says <text|/regex/> say <response>",
+			Examples:    []string{"when someone says poutine say miam", "when someone says /bonne (nuit|soiree)/ say $1 a toi aussi"},
+			Args:        `(.+?) (?:say|dis) (.+)`,
+			Handler:     (*Bot).handleTeachCommand,
+		},
+		{
+			Name:        "remind",
+			Aliases:     []string{"rappelle", "rappelle-moi"},
+			Description: "Remind you of something later, here or in private",
+			Usage:       "remind me [dm] in <duration>|at <time>|<day> [time] <message>",
+			Examples:    []string{"remind me in 2h30 to stretch", "remind me dm tomorrow 9am to call mom", "rappelle-moi dans 10 minutes de sortir le linge", "rappelle moi lundi à 8h de payer le loyer"},
+			Args:        `(?:(me|moi)\s+)?(?:(dm|pm|in private|en priv[ée])\s+)?(.+)`,
+			Handler:     (*Bot).handleRemindCommand,
+		},
+		{
+			Name:        "reminders",
+			Aliases:     []string{"rappels"},
+			Description: "List your reminders, or cancel one",
+			Usage:       "reminders [list|cancel <id>]",
+			Examples:    []string{"reminders", "reminders cancel 3"},
+			Args:        `(?:(list|liste)|(?:cancel|annule) #?(\d+))?`,
+			Handler:     (*Bot).handleRemindersCommand,
+		},
+		{
+			Name:        "triggers",
+			Description: "List the triggers taught to the bot",
+			Usage:       "triggers",
+			Handler:     (*Bot).handleTriggersCommand,
+		},
+		{
+			Name:        "forget",
+			Aliases:     []string{"oublie"},
+			Description: "Forget a taught trigger, by number",
+			Usage:       "forget <number>",
+			Examples:    []string{"forget 3"},
+			Args:        `#?(\d+)`,
+			Handler:     (*Bot).handleForgetCommand,
+		},
+		{
+			Name:        "karma",
+			Description: "Show the karma of something, or the leaderboard",
+			Usage:       "karma <thing>|top",
+			Examples:    []string{"karma poutine", "karma @huel", "karma top"},
+			Args:        `(\S+)`,
+			Handler:     (*Bot).handleKarmaCommand,
+		},
+		{
+			Name:        "huel",
+			Description: "Say something the way huel would",
+			Usage:       "huel",
+			Args:        `.*`,
+			Handler:     (*Bot).handleHuelCommand,
+		},
+		{
+			Name:        "help",
+			Aliases:     []string{"aide"},
+			Description: "List the commands, or show how to use one",
+			Usage:       "help [command]",
+			Examples:    []string{"help", "help weather"},
+			Args:        `(.*)`,
+			Handler:     (*Bot).handleHelpCommand,
+		},
+	}
+}
+
 // handleNamedCommands processes commands that start with @botname
 func (b *Bot) handleNamedCommands(post *model.Post, replyToId string) bool {
-	pattern := globalRegexOptions + "^@" + b.user.Username + " (.*)$"
-	matched := regexp.MustCompile(pattern).FindAllStringSubmatch(post.Message, -1)
+	matched := b.mentionPattern.FindStringSubmatch(post.Message)
 	if matched == nil {
 		return false
 	}
 
-	command := matched[0][1]
-	is420 := time.Now().Month() == time.April && time.Now().Day() == 20
-
-	// Try each command handler
-	handlers := []func(post *model.Post, replyToId, command string, is420 bool) bool{
-		b.handleInsultCommands,
-		b.handleThanksCommand,
-		b.handleQuestionCommand,
-		b.handleLoveCommand,
-		b.handleChargeCommands,
-		b.handleConvertCommand,
-		b.handleWeatherCommand,
-		b.handleUrbanCommand,
-		b.handleJapaneseCommands,
-		b.handleDotaCommand,
-		b.handleRollCommand,
-	}
+	command := matched[1]
+	cmd, keyword, args := b.commands.match(command)
+	if cmd != nil && (!cmd.Only420 || is420(b.userNow(post.UserId))) && b.commandEnabled(cmd, post.ChannelId) {
+		if parsed := cmd.parseArgs(args); parsed != nil {
+			if cmd.AdminOnly && !b.isAdmin(post.UserId) {
+				b.createReply(post.ChannelId, "lol no, admins only", replyToId, post.UserId)
+				return true
+			}
 
-	for _, handler := range handlers {
-		if handler(post, replyToId, command, is420) {
+			commandsTotal.Inc(cmd.Name)
+			cmd.Handler(b, &CommandRequest{
+				Post:      post,
+				ReplyToId: replyToId,
+				Keyword:   keyword,
+				Args:      parsed,
+			})
 			return true
 		}
 	}
 
 	// Default response for unrecognized commands
-	b.createPost(post.ChannelId, "Kes tu. Veux????", replyToId)
+	unknownCommandsTotal.Inc()
+	b.createPost(post.ChannelId, "Kes tu. Veux???? Try `@"+b.user.Username+" help`.", replyToId)
 	return true
 }
 
-// handleInsultCommands handles insult-type commands
-func (b *Bot) handleInsultCommands(post *model.Post, replyToId, command string, _ bool) bool {
-	if matched, _ := regexp.MatchString(globalRegexOptions+`^stfu|fuck you|fuck off|ta yeule|tayeule|shut up|shut the fuck up$`, command); matched {
-		choices := []string{"no u?", "no u", ":chuckles:", "rolf"}
-		b.createReply(post.ChannelId, randomChoice(choices), replyToId, post.UserId)
+// commandEnabled checks the per-command configuration for the given channel
+func (b *Bot) commandEnabled(cmd *Command, channelId string) bool {
+	cfg, ok := b.config.Commands[strings.ToLower(cmd.Name)]
+	if !ok {
 		return true
 	}
-	return false
+	if cfg.Disabled || slices.Contains(cfg.DisabledChannels, channelId) {
+		return false
+	}
+	return len(cfg.Channels) == 0 || slices.Contains(cfg.Channels, channelId)
 }
 
-// handleThanksCommand handles thank you commands
-func (b *Bot) handleThanksCommand(post *model.Post, replyToId, command string, _ bool) bool {
-	if matched, _ := regexp.MatchString(globalRegexOptions+`^thanks|merci|ty|thx$`, command); matched {
-		choices := []string{"de rien la", "np", "np ;)"}
-		b.createReply(post.ChannelId, randomChoice(choices), replyToId, post.UserId)
-		return true
+// isAdmin checks whether a user is one of the configured admins
+func (b *Bot) isAdmin(userId string) bool {
+	username := strings.TrimPrefix(b.getUserMention(userId), "@")
+	for _, admin := range b.config.Admins {
+		if strings.EqualFold(strings.TrimPrefix(admin, "@"), username) {
+			return true
+		}
 	}
 	return false
 }
 
+// handleInsultCommand handles insult-type commands
+func (b *Bot) handleInsultCommand(req *CommandRequest) {
+	post := req.Post
+	choices := []string{"no u?", "no u", ":chuckles:", "rolf"}
+	b.createReply(post.ChannelId, b.randomChoice(choices), req.ReplyToId, post.UserId)
+}
+
+// handleThanksCommand handles thank you commands
+func (b *Bot) handleThanksCommand(req *CommandRequest) {
+	post := req.Post
+	choices := []string{"de rien la", "np", "np ;)"}
+	b.createReply(post.ChannelId, b.randomChoice(choices), req.ReplyToId, post.UserId)
+}
+
 // handleQuestionCommand handles question commands
-func (b *Bot) handleQuestionCommand(post *model.Post, replyToId, command string, _ bool) bool {
-	if matched, _ := regexp.MatchString(globalRegexOptions+`^est-ce qu.*$`, command); matched {
-		choices := []string{"maybe", "??", "yess", "no", "rolf oui", "omgggg no"}
-		b.createReply(post.ChannelId, randomChoice(choices), replyToId, post.UserId)
-		return true
-	}
-	return false
+func (b *Bot) handleQuestionCommand(req *CommandRequest) {
+	post := req.Post
+	choices := []string{"maybe", "??", "yess", "no", "rolf oui", "omgggg no"}
+	b.createReply(post.ChannelId, b.randomChoice(choices), req.ReplyToId, post.UserId)
 }
 
 // handleLoveCommand handles love command
-func (b *Bot) handleLoveCommand(post *model.Post, replyToId, command string, _ bool) bool {
-	if matched, _ := regexp.MatchString(globalRegexOptions+`^I love you$`, command); matched {
-		b.createReply(post.ChannelId, "<3", replyToId, post.UserId)
-		return true
-	}
-	return false
+func (b *Bot) handleLoveCommand(req *CommandRequest) {
+	post := req.Post
+	b.createReply(post.ChannelId, "<3", req.ReplyToId, post.UserId)
 }
 
-// handleChargeCommands handles charge-related commands (420 only)
-func (b *Bot) handleChargeCommands(post *model.Post, _ string, command string, is420 bool) bool {
-	if !is420 {
-		return false
-	}
+// handleChargeCommand handles charge-related commands. Charging up and
+// checking your level are for 4/20 only.
+func (b *Bot) handleChargeCommand(req *CommandRequest) {
+	post := req.Post
+	sub, args := strings.ToLower(req.Args[1]), strings.Fields(req.Args[2])
 
-	if matched, _ := regexp.MatchString(globalRegexOptions+`^charge up$`, command); matched {
-		message := b.chargeUp(post.UserId, 1)
-		b.createReply(post.ChannelId, message, post.Id, post.UserId)
-		return true
-	}
+	// Close last season first so 4/20 starts from scratch
+	b.archiveChargeSeason(b.clock.Now())
 
-	if matched, _ := regexp.MatchString(globalRegexOptions+`^charge level$`, command); matched {
-		message := b.getChargeLevelMessage(post.UserId)
-		b.createReply(post.ChannelId, message, post.Id, post.UserId)
-		return true
+	// Tables are posted without a mention so they render
+	var message string
+	switch {
+	case sub == "top":
+		b.createPost(post.ChannelId, b.chargeTopMessage(), post.Id)
+		return
+	case sub == "shop":
+		b.createPost(post.ChannelId, b.chargeShopMessage(), post.Id)
+		return
+	case sub == "season" && len(args) == 1:
+		b.createPost(post.ChannelId, b.chargeSeasonMessage(args[0]), post.Id)
+		return
+	case sub == "history" && len(args) <= 1:
+		userId := post.UserId
+		if len(args) == 1 {
+			user, err := b.users.GetByUsername(context.TODO(), args[0])
+			if err != nil {
+				b.createReply(post.ChannelId, "Who's "+args[0]+"?", post.Id, post.UserId)
+				return
+			}
+			userId = user.Id
+		}
+		b.createPost(post.ChannelId, b.chargeHistoryMessage(userId), post.Id)
+		return
+	case sub == "give" && len(args) == 2:
+		message = b.chargeGiveMessage(post.UserId, args[0], args[1])
+	case sub == "bet" && len(args) == 1:
+		message = b.chargeBetMessage(post.UserId, args[0])
+	case sub == "buy" && len(args) == 1:
+		message = b.chargeBuyMessage(post.UserId, args[0])
+	case (sub == "up" || sub == "level") && len(args) == 0:
+		switch {
+		case !is420(b.userNow(post.UserId)):
+			message = "Reviens le 4/20 :weed:"
+		case sub == "up":
+			message = b.chargeUp(post.UserId, 1)
+		default:
+			message = b.getChargeLevelMessage(post.UserId)
+		}
+	default:
+		message = "Kes tu. Veux???? Try `@" + b.user.Username + " help charge`."
 	}
-
-	return false
+	b.createReply(post.ChannelId, message, post.Id, post.UserId)
 }
 
 // handleConvertCommand handles currency conversion
-func (b *Bot) handleConvertCommand(post *model.Post, _ string, command string, _ bool) bool {
-	matched := regexp.MustCompile(globalRegexOptions+`^convert( (\d+)? ?(\w{3}) (?:to )?(\w{3}))?$`).FindAllStringSubmatch(command, -1)
-	if matched == nil {
-		return false
-	}
+func (b *Bot) handleConvertCommand(req *CommandRequest) {
+	post := req.Post
 
 	// Default to 1 CAD to USD
 	from := "CAD"
 	to := "USD"
 	amount := 1.0
 
-	// If a value was provided
-	if matched[0][1] != "" {
-		var err error
-		amount, err = strconv.ParseFloat(matched[0][2], 64)
-		if err != nil {
-			b.createReply(post.ChannelId, "Couldn't convert "+matched[0][2]+" to an integer.", post.Id, post.UserId)
-			return true
+	// If currencies were provided
+	if req.Args[2] != "" {
+		if req.Args[1] != "" {
+			var err error
+			amount, err = strconv.ParseFloat(req.Args[1], 64)
+			if err != nil {
+				b.createReply(post.ChannelId, "Couldn't convert "+req.Args[1]+" to an integer.", post.Id, post.UserId)
+				return
+			}
 		}
-		from = strings.ToUpper(matched[0][3])
-		to = strings.ToUpper(matched[0][4])
+		from = strings.ToUpper(req.Args[2])
+		to = strings.ToUpper(req.Args[3])
 	}
 
 	amountStr := strconv.FormatFloat(amount, 'f', 2, 64)
 	convertedValue, err := commands.Convert(from, to, amount)
 	if err != nil {
 		b.createReply(post.ChannelId, "Couldn't convert "+amountStr+" "+from+" to "+to+".", post.Id, post.UserId)
-		return true
+		return
 	}
 
 	message := amountStr + " " + from + " = " + strconv.FormatFloat(convertedValue, 'f', 5, 64) + " " + to
 	b.createReply(post.ChannelId, message, post.Id, post.UserId)
-	return true
 }
 
 // handleWeatherCommand handles weather queries
-func (b *Bot) handleWeatherCommand(post *model.Post, _ string, command string, _ bool) bool {
-	matched := regexp.MustCompile(globalRegexOptions+`^weather ?((now) (.*)|(.*))$`).FindAllStringSubmatch(command, -1)
-	if matched == nil {
-		return false
-	}
+func (b *Bot) handleWeatherCommand(req *CommandRequest) {
+	post := req.Post
+	now := strings.EqualFold(req.Args[1], "now")
 
-	location := ""
-	subcommand := strings.ToLower(matched[0][1])
+	location := req.Args[3]
+	if now {
+		location = req.Args[2]
+	}
 
 	// Default to Montreal if no location is provided
-	if subcommand == "" || (subcommand == "now" && matched[0][2] == "") {
+	if location == "" {
 		location = "Montreal"
-	} else if subcommand != "" && subcommand != "now" {
-		location = subcommand
-	} else {
-		location = matched[0][2]
 	}
 
 	var message string
 	var err error
 
-	if subcommand == "now" {
+	if now {
 		message, err = b.weatherClient.GetCurrentWeather(location)
 	} else {
 		message, err = b.weatherClient.GetWeather(location)
@@ -176,104 +391,86 @@ func (b *Bot) handleWeatherCommand(post *model.Post, _ string, command string, _
 
 	if err != nil {
 		b.createReply(post.ChannelId, "Couldn't get weather for "+location+".", post.Id, post.UserId)
-		return true
+		return
 	}
 
 	b.createPost(post.ChannelId, message, post.Id)
-	return true
 }
 
 // handleUrbanCommand handles Urban Dictionary lookups
-func (b *Bot) handleUrbanCommand(post *model.Post, _ string, command string, _ bool) bool {
-	matched := regexp.MustCompile(globalRegexOptions+`^urban(?: (.*))?$`).FindAllStringSubmatch(command, -1)
-	if matched == nil {
-		return false
-	}
+func (b *Bot) handleUrbanCommand(req *CommandRequest) {
+	post := req.Post
 
 	word := "huel"
-	if matched[0][1] != "" {
-		word = matched[0][1]
+	if req.Args[1] != "" {
+		word = req.Args[1]
 	}
 
 	result, err := commands.GetUrbanDictionaryDefinition(word)
 	if err != nil {
 		b.createReply(post.ChannelId, "Couldn't get definition for "+word+".", post.Id, post.UserId)
-		return true
+		return
 	}
 
 	message := fmt.Sprintf("%s\n\n_%s_\n\n**by: %s**\n\n`%d`:+1: `%d`:-1:",
 		result.Definition, result.Example, result.Author, result.Upvote, result.Downvote)
 	b.createPost(post.ChannelId, message, post.Id)
-	return true
 }
 
-// handleJapaneseCommands handles Japanese language commands
-func (b *Bot) handleJapaneseCommands(post *model.Post, _ string, command string, _ bool) bool {
-	// Romaji conversion
-	if matched := regexp.MustCompile(globalRegexOptions+`^romaji(?: (.*))?$`).FindAllStringSubmatch(command, -1); matched != nil {
-		if matched[0][1] == "" {
-			b.createReply(post.ChannelId, "Please provide a word to convert.", post.Id, post.UserId)
-			return true
-		}
-		b.createPost(post.ChannelId, kana.KanaToRomaji(matched[0][1]), post.Id)
-		return true
-	}
+// handleRomajiCommand converts kana to romaji
+func (b *Bot) handleRomajiCommand(req *CommandRequest) {
+	b.convertKana(req, kana.KanaToRomaji)
+}
 
-	// Hiragana conversion
-	if matched := regexp.MustCompile(globalRegexOptions+`^hiragana(?: (.*))?$`).FindAllStringSubmatch(command, -1); matched != nil {
-		if matched[0][1] == "" {
-			b.createReply(post.ChannelId, "Please provide a word to convert.", post.Id, post.UserId)
-			return true
-		}
-		b.createPost(post.ChannelId, kana.RomajiToHiragana(matched[0][1]), post.Id)
-		return true
-	}
+// handleHiraganaCommand converts romaji to hiragana
+func (b *Bot) handleHiraganaCommand(req *CommandRequest) {
+	b.convertKana(req, kana.RomajiToHiragana)
+}
 
-	// Katakana conversion
-	if matched := regexp.MustCompile(globalRegexOptions+`^katakana(?: (.*))?$`).FindAllStringSubmatch(command, -1); matched != nil {
-		if matched[0][1] == "" {
-			b.createReply(post.ChannelId, "Please provide a word to convert.", post.Id, post.UserId)
-			return true
-		}
-		b.createPost(post.ChannelId, kana.RomajiToKatakana(matched[0][1]), post.Id)
-		return true
-	}
+// handleKatakanaCommand converts romaji to katakana
+func (b *Bot) handleKatakanaCommand(req *CommandRequest) {
+	b.convertKana(req, kana.RomajiToKatakana)
+}
 
-	// Word of the day (Japanese)
-	if matched := regexp.MustCompile(globalRegexOptions+`^wotd japanese.*$`).FindAllStringSubmatch(command, -1); matched != nil {
-		message, err := commands.GetWotdJapanese()
-		if err != nil {
-			b.createReply(post.ChannelId, "Couldn't get WotD Japanese.", post.Id, post.UserId)
-			return true
-		}
-		b.createPost(post.ChannelId, message, post.Id)
-		return true
+// convertKana replies with the argument converted by convert
+func (b *Bot) convertKana(req *CommandRequest, convert func(string) string) {
+	post := req.Post
+	if req.Args[1] == "" {
+		b.createReply(post.ChannelId, "Please provide a word to convert.", post.Id, post.UserId)
+		return
 	}
+	b.createPost(post.ChannelId, convert(req.Args[1]), post.Id)
+}
 
-	return false
+// handleWotdCommand handles the Japanese word of the day
+func (b *Bot) handleWotdCommand(req *CommandRequest) {
+	post := req.Post
+	message, err := commands.GetWotdJapanese()
+	if err != nil {
+		b.createReply(post.ChannelId, "Couldn't get WotD Japanese.", post.Id, post.UserId)
+		return
+	}
+	b.createPost(post.ChannelId, message, post.Id)
 }
 
 // handleDotaCommand handles Dota MMR lookups
-func (b *Bot) handleDotaCommand(post *model.Post, _ string, command string, _ bool) bool {
-	matched := regexp.MustCompile(globalRegexOptions+`^mmr(?: (\d+))?$`).FindAllStringSubmatch(command, -1)
-	if matched == nil {
-		return false
-	}
+func (b *Bot) handleDotaCommand(req *CommandRequest) {
+	post := req.Post
 
 	playerId := 12088460
-	if matched[0][1] != "" {
+	if req.Args[1] != "" {
 		var err error
-		playerId, err = strconv.Atoi(matched[0][1])
+		playerId, err = strconv.Atoi(req.Args[1])
 		if err != nil || len(strconv.Itoa(playerId)) > 10 {
 			b.createReply(post.ChannelId, fmt.Sprintf("lel nice fake player id: %d.", playerId), post.Id, post.UserId)
-			return true
+			return
 		}
 	}
 
 	mmr, err := commands.GetDotaMMR(playerId)
 	if err != nil {
 		b.createReply(post.ChannelId, fmt.Sprintf("rofl %d existe meme pas zzz", playerId), post.Id, post.UserId)
-		return true
+		return
 	}
 
 	var message string
@@ -281,7 +478,7 @@ func (b *Bot) handleDotaCommand(post *model.Post, _ string, command string, _ bo
 	case 12088460:
 		message = fmt.Sprintf("lel j'suis rendu %d ez gaem road to 4k", mmr.SoloCompetitiveRank)
 		b.createReply(post.ChannelId, message, post.Id, post.UserId)
-		return true
+		return
 	case 53515020:
 		mmr.SoloCompetitiveRank = 9000
 	}
@@ -296,40 +493,52 @@ func (b *Bot) handleDotaCommand(post *model.Post, _ string, command string, _ bo
 	}
 
 	b.createReply(post.ChannelId, message, post.Id, post.UserId)
-	return true
 }
 
-// handleRollCommand handles dice rolling
-func (b *Bot) handleRollCommand(post *model.Post, _ string, command string, _ bool) bool {
-	matched := regexp.MustCompile(globalRegexOptions+`^roll(?: (\d+|:weed:))?\s*$`).FindAllStringSubmatch(command, -1)
-	if matched == nil {
-		return false
-	}
+// handleRollCommand handles dice rolling. A bare number rolls a single die
+// with the special 420 logic, anything else is dice notation.
+func (b *Bot) handleRollCommand(req *CommandRequest) {
+	post := req.Post
 
-	requestedRoll := 0
-	switch matched[0][1] {
-	case "", ":weed:":
-		requestedRoll = 420
-	case "dice":
-		requestedRoll = 6
+	var message string
+	switch arg := req.Args[1]; {
+	case arg == "" || arg == ":weed:":
+		message = b.rollDice(420, post.UserId)
+	case strings.EqualFold(arg, "dice"):
+		message = b.rollDice(6, post.UserId)
+	case isNumber(arg):
+		sides, err := strconv.Atoi(arg)
+		if err != nil || sides < 1 {
+			message = "Kes tu roll? A die needs at least one side"
+			break
+		}
+		message = b.rollDice(sides, post.UserId)
 	default:
-		requestedRoll, _ = strconv.Atoi(matched[0][1])
+		roll, err := commands.ParseDice(arg)
+		if err != nil {
+			message = "Kes tu roll? " + err.Error()
+			break
+		}
+		message = roll.Roll(b.random.Intn).String()
 	}
-
-	message := b.rollDice(requestedRoll, post.UserId)
 	b.createReply(post.ChannelId, message, post.Id, post.UserId)
-	return true
+}
+
+// isNumber tells whether s only has digits
+func isNumber(s string) bool {
+	return strings.Trim(s, "0123456789") == ""
 }
 
 // rollDice performs a dice roll with special 420 logic
 func (b *Bot) rollDice(dice int, userId string) string {
-	roll := rand.Intn(dice) + 1
+	roll := b.random.Intn(dice) + 1
 
 	if dice == 420 {
-		now := time.Now()
+		// Everybody gets their own 4:20
+		now := b.userNow(userId)
 		if now.Hour()%12 == 4 && now.Minute() == 20 {
 			chargeBonus := b.getCharge(userId)
-			if now.Month() == time.April && now.Day() == 20 && chargeBonus != 0 {
+			if is420(now) && chargeBonus != 0 {
 				actualRoll := roll
 				roll = actualRoll + chargeBonus
 				message := strconv.Itoa(actualRoll) + " + " + strconv.Itoa(chargeBonus) + " charge bonus = " + strconv.Itoa(roll) + " "
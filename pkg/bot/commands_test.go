@@ -0,0 +1,52 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/opendwellers/jujubot/pkg/config"
+	"github.com/opendwellers/jujubot/pkg/store"
+)
+
+func TestRollDice(t *testing.T) {
+	normalDay := time.Date(2026, time.January, 15, 4, 20, 0, 0, time.UTC)
+	day420 := time.Date(2026, time.April, 20, 16, 20, 30, 0, time.UTC)
+	tests := []struct {
+		name   string
+		now    time.Time
+		dice   int
+		charge int
+		// draw is the random number drawn, the roll minus 1
+		draw int64
+		want string
+	}{
+		{"not 4:20", testNow, 420, 0, 99, "Spa leur smh"},
+		{"4:21", normalDay.Add(time.Minute), 420, 0, 99, "Spa leur smh"},
+		{"4:19", normalDay.Add(-time.Second), 420, 0, 99, "Spa leur smh"},
+		{"4:20 am", normalDay, 420, 0, 99, "100 :chuckles:"},
+		{"4:20 pm", normalDay.Add(12 * time.Hour), 420, 0, 99, "100 :chuckles:"},
+		{"420", normalDay, 420, 0, 419, "420 BIG WINNER WOW :musk: :weed:"},
+		{"69", normalDay, 420, 0, 68, "69 _Nice._ :smugpepe:"},
+		{"no bonus on a normal day", normalDay, 420, 10, 99, "100 :chuckles:"},
+		{"4/20 without charge", day420, 420, 0, 99, "100 :chuckles:"},
+		{"4/20 with charge", day420, 420, 10, 99, "100 + 10 charge bonus = 110 :chuckles:"},
+		{"4/20 with negative charge", day420, 420, -50, 99, "100 + -50 charge bonus = 50 :chuckles:"},
+		{"bonus lands on 420", day420, 420, 20, 399, "400 + 20 charge bonus = 420 BIG WINNER WOW :musk: :weed:"},
+		{"bonus lands on 69", day420, 420, -31, 99, "100 + -31 charge bonus = 69 _Nice._ :smugpepe:"},
+		{"bonus misses 420", day420, 420, 1, 419, "420 + 1 charge bonus = 421 :chuckles:"},
+		{"regular die", testNow, 6, 0, 3, "4"},
+		{"regular die at 4:20", normalDay, 6, 10, 5, "6"},
+		{"one-sided die", testNow, 1, 0, 0, ":99:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := newTestBot(t, config.Config{}, WithClock(NewFakeClock(tt.now)), WithRandom(NewScriptedRandom(tt.draw)))
+			if err := store.Put(b.store, chargeCollection, "alice", tt.charge); err != nil {
+				t.Fatal(err)
+			}
+			if got := b.rollDice(tt.dice, "alice"); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
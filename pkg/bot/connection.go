@@ -0,0 +1,302 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"go.uber.org/zap"
+)
+
+const (
+	backoffBase       = time.Second
+	backoffMax        = 2 * time.Minute
+	backoffResetAfter = time.Minute
+	heartbeatInterval = 30 * time.Second
+	heartbeatTimeout  = 90 * time.Second
+	seenPostsCapacity = 1000
+)
+
+// connState is the state of the WebSocket connection
+type connState int32
+
+const (
+	stateDisconnected connState = iota
+	stateConnecting
+	stateConnected
+	stateBackoff
+)
+
+func (s connState) String() string {
+	switch s {
+	case stateConnecting:
+		return "connecting"
+	case stateConnected:
+		return "connected"
+	case stateBackoff:
+		return "backoff"
+	default:
+		return "disconnected"
+	}
+}
+
+// backoff computes jittered exponential reconnection delays
+type backoff struct {
+	base    time.Duration
+	max     time.Duration
+	random  Random
+	attempt int
+}
+
+// next returns the delay before the next attempt: half of the exponential
+// delay is fixed and the other half is random, so reconnecting clients spread out
+func (bo *backoff) next() time.Duration {
+	delay := bo.max
+	if bo.attempt < 32 && bo.base<<bo.attempt < bo.max {
+		delay = bo.base << bo.attempt
+	}
+	bo.attempt++
+
+	half := delay / 2
+	return half + time.Duration(bo.random.Int63n(int64(half)+1))
+}
+
+// reset starts the exponential sequence over
+func (bo *backoff) reset() {
+	bo.attempt = 0
+}
+
+// connection tracks the WebSocket state and which posts were already handled
+type connection struct {
+	state        atomic.Int32
+	stateSince   atomic.Int64 // unix nanoseconds of the last connect or disconnect
+	lastActivity atomic.Int64 // unix nanoseconds of the last event or pong
+	lastEventAt  atomic.Int64 // unix nanoseconds of the last event
+	lastPostAt   atomic.Int64 // CreateAt (ms) of the newest post seen
+
+	// Reconnection and heartbeat timings, the defaults above unless changed
+	// in tests
+	backoffBase       time.Duration
+	backoffMax        time.Duration
+	heartbeatInterval time.Duration
+	heartbeatTimeout  time.Duration
+
+	mu       sync.Mutex
+	seen     map[string]struct{}
+	seenRing []string
+	seenNext int
+}
+
+func newConnection() *connection {
+	return &connection{
+		backoffBase:       backoffBase,
+		backoffMax:        backoffMax,
+		heartbeatInterval: heartbeatInterval,
+		heartbeatTimeout:  heartbeatTimeout,
+		seen:              make(map[string]struct{}, seenPostsCapacity),
+		seenRing:          make([]string, seenPostsCapacity),
+	}
+}
+
+func (c *connection) setState(state connState) {
+	previous := connState(c.state.Swap(int32(state)))
+	if (previous == stateConnected) != (state == stateConnected) || c.stateSince.Load() == 0 {
+		c.stateSince.Store(time.Now().UnixNano())
+	}
+}
+
+func (c *connection) getState() connState {
+	return connState(c.state.Load())
+}
+
+// touch records that the server just showed signs of life
+func (c *connection) touch() {
+	c.lastActivity.Store(time.Now().UnixNano())
+}
+
+// eventReceived records that an event just arrived
+func (c *connection) eventReceived() {
+	now := time.Now().UnixNano()
+	c.lastActivity.Store(now)
+	c.lastEventAt.Store(now)
+}
+
+// idleFor returns how long ago the server last showed signs of life
+func (c *connection) idleFor() time.Duration {
+	return time.Since(time.Unix(0, c.lastActivity.Load()))
+}
+
+// markSeen records a post and returns false if it was already handled
+func (c *connection) markSeen(post *model.Post) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.seen[post.Id]; ok {
+		return false
+	}
+
+	// Forget the oldest post once the ring is full
+	if oldest := c.seenRing[c.seenNext]; oldest != "" {
+		delete(c.seen, oldest)
+	}
+	c.seenRing[c.seenNext] = post.Id
+	c.seenNext = (c.seenNext + 1) % len(c.seenRing)
+	c.seen[post.Id] = struct{}{}
+
+	if post.CreateAt > c.lastPostAt.Load() {
+		c.lastPostAt.Store(post.CreateAt)
+	}
+	return true
+}
+
+// startWebSocketListener keeps a WebSocket connection open until ctx is cancelled,
+// reconnecting with backoff and catching up on posts missed in between
+func (b *Bot) startWebSocketListener(ctx context.Context) {
+	bo := backoff{base: b.conn.backoffBase, max: b.conn.backoffMax, random: b.random}
+	connectedBefore := false
+
+	for ctx.Err() == nil {
+		b.setConnState(stateConnecting)
+		stream, err := b.client.Connect()
+		if err != nil {
+			var appErr *model.AppError
+			if errors.As(err, &appErr) && appErr.StatusCode == http.StatusUnauthorized {
+				zap.S().Error("WebSocket authentication was rejected")
+				b.authenticated.Store(false)
+			}
+
+			delay := bo.next()
+			zap.S().Error("Failed to connect to WebSocket, retrying in ", delay, zap.Error(err))
+			b.setConnState(stateBackoff)
+			sleepContext(ctx, delay)
+			continue
+		}
+
+		connectedAt := time.Now()
+		b.authenticated.Store(true)
+		b.setConnState(stateConnected)
+		b.conn.touch()
+		b.conn.lastPostAt.CompareAndSwap(0, connectedAt.UnixMilli())
+		zap.S().Info("Connected to WebSocket")
+
+		if connectedBefore {
+			b.catchUp(ctx)
+		}
+		connectedBefore = true
+
+		b.listen(ctx, stream)
+		b.setConnState(stateDisconnected)
+
+		if time.Since(connectedAt) > backoffResetAfter {
+			bo.reset()
+		}
+		if ctx.Err() == nil {
+			delay := bo.next()
+			zap.S().Info("Reconnecting to WebSocket in ", delay)
+			b.setConnState(stateBackoff)
+			sleepContext(ctx, delay)
+		}
+	}
+}
+
+// listen handles incoming WebSocket events until the stream ends, stops
+// answering heartbeats or ctx is cancelled
+func (b *Bot) listen(ctx context.Context, stream EventStream) {
+	defer stream.Close()
+
+	heartbeat := time.NewTicker(b.conn.heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-stream.Pongs():
+			b.conn.touch()
+		case <-heartbeat.C:
+			if idle := b.conn.idleFor(); idle > b.conn.heartbeatTimeout {
+				zap.S().Warn("WebSocket silent for ", idle, ", reconnecting")
+				return
+			}
+			stream.Ping()
+		case event, ok := <-stream.Events():
+			if !ok {
+				if err := stream.Err(); err != nil {
+					zap.S().Error("WebSocket listen error", zap.Error(err))
+				}
+				return
+			}
+			b.conn.eventReceived()
+			if event == nil {
+				continue
+			}
+
+			b.dispatch(ctx, event)
+		}
+	}
+}
+
+// catchUp fetches posts created while the WebSocket was down and dispatches
+// them like live events. Posts already handled are skipped by handleEvent.
+func (b *Bot) catchUp(ctx context.Context) {
+	since := b.conn.lastPostAt.Load()
+
+	channels, err := b.client.GetChannelsForUser(ctx, b.team.Id, b.user.Id)
+	if err != nil {
+		zap.S().Error("Failed to list channels for catch-up", zap.Error(err))
+		return
+	}
+
+	var missed []*model.Post
+	for _, channel := range channels {
+		posts, err := b.client.GetPostsSince(ctx, channel.Id, since)
+		if err != nil {
+			zap.S().Error("Failed to get posts for channel "+channel.Name, zap.Error(err))
+			continue
+		}
+		for _, post := range posts {
+			// GetPostsSince also returns posts edited since then
+			if post.CreateAt > since {
+				missed = append(missed, post)
+			}
+		}
+	}
+
+	sort.Slice(missed, func(i, j int) bool { return missed[i].CreateAt < missed[j].CreateAt })
+	zap.S().Info("Catching up on ", len(missed), " missed posts")
+
+	for _, post := range missed {
+		event, err := newPostedEvent(post)
+		if err != nil {
+			zap.S().Error("Failed to encode missed post", zap.Error(err))
+			continue
+		}
+		b.dispatch(ctx, event)
+	}
+}
+
+// newPostedEvent wraps a post in a posted WebSocket event
+func newPostedEvent(post *model.Post) (*model.WebSocketEvent, error) {
+	data, err := post.ToJSON()
+	if err != nil {
+		return nil, err
+	}
+	event := model.NewWebSocketEvent(model.WebsocketEventPosted, "", post.ChannelId, "", nil, "")
+	event.Add("post", data)
+	return event, nil
+}
+
+// sleepContext waits for the given duration or until ctx is cancelled
+func sleepContext(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
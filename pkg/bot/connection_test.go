@@ -0,0 +1,204 @@
+package bot
+
+import (
+	"context"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/opendwellers/jujubot/pkg/config"
+)
+
+// maxRandom is a Random always drawing its highest value
+type maxRandom struct{}
+
+func (maxRandom) Intn(n int) int       { return n - 1 }
+func (maxRandom) Int63n(n int64) int64 { return n - 1 }
+func (maxRandom) Float64() float64     { return 0.999 }
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name   string
+		random Random
+		want   []time.Duration
+	}{
+		{"lowest jitter", NewScriptedRandom(), []time.Duration{
+			500 * time.Millisecond, time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second,
+			16 * time.Second, 32 * time.Second, time.Minute, time.Minute,
+		}},
+		{"highest jitter", maxRandom{}, []time.Duration{
+			time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second,
+			32 * time.Second, 64 * time.Second, 2 * time.Minute, 2 * time.Minute,
+		}},
+		{"jitter in between", NewScriptedRandom(250*int64(time.Millisecond), 0, int64(time.Second)), []time.Duration{
+			750 * time.Millisecond, time.Second, 3 * time.Second,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bo := backoff{base: backoffBase, max: backoffMax, random: tt.random}
+			for i, want := range tt.want {
+				if got := bo.next(); got != want {
+					t.Errorf("attempt %d: got %v, want %v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestBackoffCapAndReset(t *testing.T) {
+	bo := backoff{base: backoffBase, max: backoffMax, random: maxRandom{}}
+	// Far past the point where the shift would overflow
+	var got time.Duration
+	for range 100 {
+		if got = bo.next(); got > backoffMax || got <= 0 {
+			t.Fatalf("attempt %d: got %v, want at most %v", bo.attempt, got, backoffMax)
+		}
+	}
+	if got != backoffMax {
+		t.Errorf("got %v after 100 attempts, want %v", got, backoffMax)
+	}
+	bo.reset()
+	if got := bo.next(); got != time.Second {
+		t.Errorf("got %v after reset, want 1s", got)
+	}
+}
+
+func TestCatchUp(t *testing.T) {
+	b, client := newTestBot(t, config.Config{})
+	b.team = &model.Team{Id: "team", Name: "team"}
+	client.AddChannel(&model.Channel{Id: testChannelId, Name: "town-square", TeamId: "team"})
+	client.AddChannel(&model.Channel{Id: "off-topic", Name: "off-topic", TeamId: "team"})
+
+	ctx := context.Background()
+	b.dispatcher.start()
+	b.conn.lastPostAt.Store(1000)
+
+	handledBefore := &model.Post{Id: model.NewId(), ChannelId: testChannelId, UserId: "alice", Message: "@jujubot merci", CreateAt: 900}
+	replayed := &model.Post{Id: model.NewId(), ChannelId: testChannelId, UserId: "alice", Message: "@jujubot I love you", CreateAt: 1100}
+	missed := &model.Post{Id: model.NewId(), ChannelId: "off-topic", UserId: "bob", Message: "@jujubot ta yeule", CreateAt: 1200}
+	for _, post := range []*model.Post{handledBefore, replayed, missed} {
+		client.AddHistory(post)
+	}
+
+	// The WebSocket replays a post right after reconnecting
+	event, err := newPostedEvent(replayed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.dispatch(ctx, event)
+	b.catchUp(ctx)
+	b.catchUp(ctx)
+	b.dispatcher.stop()
+
+	got := postedMessages(client)
+	slices.Sort(got)
+	want := []string{"@alice: <3", "@bob: no u?"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if last := b.conn.lastPostAt.Load(); last != 1200 {
+		t.Errorf("last post at %d, want 1200", last)
+	}
+}
+
+// silentClient is a FakeClient whose streams never answer pings
+type silentClient struct {
+	*FakeClient
+	connects atomic.Int32
+}
+
+func (c *silentClient) Connect() (EventStream, error) {
+	c.connects.Add(1)
+	return &silentStream{events: make(chan *model.WebSocketEvent)}, nil
+}
+
+type silentStream struct {
+	events chan *model.WebSocketEvent
+	closed atomic.Bool
+}
+
+func (s *silentStream) Events() <-chan *model.WebSocketEvent { return s.events }
+func (s *silentStream) Ping()                                {}
+func (s *silentStream) Pongs() <-chan struct{}               { return nil }
+func (s *silentStream) Err() error                           { return nil }
+func (s *silentStream) Close()                               { s.closed.Store(true) }
+
+// fastHeartbeat makes the heartbeat and reconnections quick enough for tests
+func fastHeartbeat(b *Bot) {
+	b.conn.backoffBase = time.Millisecond
+	b.conn.backoffMax = 10 * time.Millisecond
+	b.conn.heartbeatInterval = 10 * time.Millisecond
+	b.conn.heartbeatTimeout = 30 * time.Millisecond
+}
+
+func TestMissedPongReconnects(t *testing.T) {
+	b, fake := newTestBot(t, config.Config{})
+	b.team = &model.Team{Id: "team", Name: "team"}
+	client := &silentClient{FakeClient: fake}
+	b.client = client
+	fastHeartbeat(b)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.startWebSocketListener(ctx)
+	}()
+
+	deadline := time.After(5 * time.Second)
+	for client.connects.Load() < 3 {
+		select {
+		case <-deadline:
+			t.Fatalf("got %d connections, want a reconnection after each missed pong", client.connects.Load())
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+	cancel()
+	<-done
+}
+
+func TestPongsKeepConnection(t *testing.T) {
+	b, _ := newTestBot(t, config.Config{})
+	fastHeartbeat(b)
+	stream := &fakeStream{events: make(chan *model.WebSocketEvent), pongs: make(chan struct{}, 1)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.conn.touch()
+		b.listen(ctx, stream)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("listen returned though the server answered every ping")
+	case <-time.After(200 * time.Millisecond):
+	}
+	cancel()
+	<-done
+}
+
+func TestSilentStreamClosed(t *testing.T) {
+	b, _ := newTestBot(t, config.Config{})
+	fastHeartbeat(b)
+	stream := &silentStream{events: make(chan *model.WebSocketEvent)}
+
+	b.conn.touch()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.listen(context.Background(), stream)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("listen kept waiting on a silent server")
+	}
+	if !stream.closed.Load() {
+		t.Error("stream not closed")
+	}
+}
@@ -0,0 +1,104 @@
+package bot
+
+import (
+	"context"
+	"hash/fnv"
+	"strconv"
+	"sync"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// dispatcher hands events to a fixed pool of workers. All events of a
+// channel go to the same worker, so replies within a channel stay in order
+// while slow handlers in one channel don't hold up the others.
+type dispatcher struct {
+	queues []chan *model.WebSocketEvent
+	handle func(event *model.WebSocketEvent)
+	done   func()
+	wg     sync.WaitGroup
+}
+
+// newDispatcher creates a dispatcher with the given number of workers, each
+// buffering up to queueSize events. done is called after every handled or
+// dropped event.
+func newDispatcher(workers, queueSize int, handle func(event *model.WebSocketEvent), done func()) *dispatcher {
+	if workers < 1 {
+		workers = 1
+	}
+	if queueSize < 1 {
+		queueSize = 1
+	}
+
+	d := &dispatcher{
+		queues: make([]chan *model.WebSocketEvent, workers),
+		handle: handle,
+		done:   done,
+	}
+	for i := range d.queues {
+		d.queues[i] = make(chan *model.WebSocketEvent, queueSize)
+		eventQueueDepth.Set(0, strconv.Itoa(i))
+	}
+	return d
+}
+
+// start launches the workers
+func (d *dispatcher) start() {
+	for i, queue := range d.queues {
+		d.wg.Add(1)
+		go d.work(strconv.Itoa(i), queue)
+	}
+}
+
+func (d *dispatcher) work(worker string, queue <-chan *model.WebSocketEvent) {
+	defer d.wg.Done()
+	for event := range queue {
+		eventQueueDepth.Set(float64(len(queue)), worker)
+		d.handle(event)
+		d.done()
+	}
+}
+
+// dispatch queues an event on the worker owning its channel. When that
+// worker is full it blocks, pushing back on the WebSocket reader. If ctx is
+// cancelled first the event is dropped: done is called and false returned.
+func (d *dispatcher) dispatch(ctx context.Context, event *model.WebSocketEvent) bool {
+	var channelId string
+	if broadcast := event.GetBroadcast(); broadcast != nil {
+		channelId = broadcast.ChannelId
+	}
+	i := d.worker(channelId)
+	queue := d.queues[i]
+	worker := strconv.Itoa(i)
+
+	select {
+	case queue <- event:
+	default:
+		eventQueueFullTotal.Inc(worker)
+		select {
+		case queue <- event:
+		case <-ctx.Done():
+			d.done()
+			return false
+		}
+	}
+
+	eventQueueDepth.Set(float64(len(queue)), worker)
+	return true
+}
+
+// worker picks the worker for a channel
+func (d *dispatcher) worker(channelId string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(channelId))
+	return int(h.Sum32() % uint32(len(d.queues)))
+}
+
+// stop waits for the queued events to be handled and stops the workers.
+// dispatch must not be called afterwards.
+func (d *dispatcher) stop() {
+	for _, queue := range d.queues {
+		close(queue)
+	}
+	d.wg.Wait()
+}
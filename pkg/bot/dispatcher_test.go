@@ -0,0 +1,157 @@
+package bot
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+func newChannelEvent(channelId string, seq int) *model.WebSocketEvent {
+	event := model.NewWebSocketEvent(model.WebsocketEventPosted, "", channelId, "", nil, "")
+	event.Add("seq", seq)
+	return event
+}
+
+func channelOf(event *model.WebSocketEvent) string {
+	return event.GetBroadcast().ChannelId
+}
+
+func TestDispatcherKeepsChannelOrder(t *testing.T) {
+	var mu sync.Mutex
+	handled := map[string][]int{}
+	d := newDispatcher(4, 8, func(event *model.WebSocketEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		channelId := channelOf(event)
+		handled[channelId] = append(handled[channelId], event.GetData()["seq"].(int))
+	}, func() {})
+	d.start()
+
+	channels := []string{"town-square", "off-topic", "random", "dev"}
+	for i := 0; i < 100; i++ {
+		for _, channelId := range channels {
+			if !d.dispatch(context.Background(), newChannelEvent(channelId, i)) {
+				t.Fatal("dispatch failed")
+			}
+		}
+	}
+	d.stop()
+
+	for _, channelId := range channels {
+		seqs := handled[channelId]
+		if len(seqs) != 100 {
+			t.Fatalf("%s: got %d events, want 100", channelId, len(seqs))
+		}
+		for i, seq := range seqs {
+			if seq != i {
+				t.Fatalf("%s: got event %d at position %d", channelId, seq, i)
+			}
+		}
+	}
+}
+
+func TestDispatcherSlowChannel(t *testing.T) {
+	release := make(chan struct{})
+	handled := make(chan string, 1)
+	d := newDispatcher(2, 1, func(event *model.WebSocketEvent) {
+		if channelOf(event) == "slow" {
+			<-release
+			return
+		}
+		handled <- channelOf(event)
+	}, func() {})
+	d.start()
+	defer d.stop()
+	defer close(release)
+
+	fast := "fast"
+	for i := 0; d.worker(fast) == d.worker("slow"); i++ {
+		fast = "fast" + string(rune('a'+i))
+	}
+
+	d.dispatch(context.Background(), newChannelEvent("slow", 0))
+	d.dispatch(context.Background(), newChannelEvent("slow", 1))
+	d.dispatch(context.Background(), newChannelEvent(fast, 0))
+
+	select {
+	case got := <-handled:
+		if got != fast {
+			t.Errorf("got %q handled, want %q", got, fast)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("fast channel blocked by slow channel")
+	}
+}
+
+func TestDispatcherFullQueue(t *testing.T) {
+	var done atomic.Int32
+	d := newDispatcher(1, 1, func(event *model.WebSocketEvent) {}, func() { done.Add(1) })
+
+	// The workers aren't started, so the first event fills the queue
+	if !d.dispatch(context.Background(), newChannelEvent("town-square", 0)) {
+		t.Fatal("dispatch into an empty queue failed")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if d.dispatch(ctx, newChannelEvent("town-square", 1)) {
+		t.Error("dispatch into a full queue with a cancelled context succeeded")
+	}
+	if got := done.Load(); got != 1 {
+		t.Errorf("done called %d times for the dropped event, want 1", got)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if d.dispatch(ctx, newChannelEvent("town-square", 2)) {
+		t.Error("dispatch into a full queue succeeded before the deadline")
+	}
+	if got := done.Load(); got != 2 {
+		t.Errorf("done called %d times after two dropped events, want 2", got)
+	}
+
+	d.start()
+	d.stop()
+	if got := done.Load(); got != 3 {
+		t.Errorf("done called %d times after handling the queued event, want 3", got)
+	}
+}
+
+func TestDispatcherStopWaits(t *testing.T) {
+	release := make(chan struct{})
+	var handled atomic.Int32
+	d := newDispatcher(2, 4, func(event *model.WebSocketEvent) {
+		<-release
+		handled.Add(1)
+	}, func() {})
+	d.start()
+	for i := 0; i < 3; i++ {
+		d.dispatch(context.Background(), newChannelEvent("town-square", i))
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		d.stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		t.Fatal("stop returned while a handler was running")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("stop didn't return after the handlers finished")
+	}
+	if got := handled.Load(); got != 3 {
+		t.Errorf("got %d events handled before stop returned, want 3", got)
+	}
+}
@@ -0,0 +1,211 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/opendwellers/jujubot/pkg/store"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	// chargeInventoryCollection stores the items bought by each user, keyed by user ID
+	chargeInventoryCollection = "charge_inventory"
+	// chargeFlairCollection stores the flair worn by each user, keyed by user ID
+	chargeFlairCollection = "charge_flair"
+)
+
+// errNotEnoughCharge is returned when spending more points than one has
+var errNotEnoughCharge = errors.New("not enough charge points")
+
+// shopItem is a cosmetic reward bought with charge points
+type shopItem struct {
+	name  string
+	price int
+	// flair is added after the user's name when the bot replies to them
+	flair string
+}
+
+// shopItems are the items for sale, cheapest first
+var shopItems = []shopItem{
+	{name: "leaf", price: 10, flair: ":herb:"},
+	{name: "fire", price: 25, flair: ":fire:"},
+	{name: "weed", price: 42, flair: ":weed:"},
+	{name: "crown", price: 69, flair: ":crown:"},
+	{name: "pogchampignon", price: 420, flair: ":pogchampignon:"},
+}
+
+// findShopItem returns the item with the given name, or nil
+func findShopItem(name string) *shopItem {
+	for i := range shopItems {
+		if strings.EqualFold(shopItems[i].name, name) {
+			return &shopItems[i]
+		}
+	}
+	return nil
+}
+
+// spendCharge takes points from a user within a transaction, failing
+// rather than going below zero
+func spendCharge(tx store.Tx, event chargeEvent) (int, error) {
+	var charge int
+	if _, err := tx.Get(chargeCollection, event.UserId, &charge); err != nil {
+		return 0, err
+	}
+	if charge < -event.Delta {
+		return 0, errNotEnoughCharge
+	}
+	return addCharge(tx, event)
+}
+
+// chargeGiveMessage moves points from one user to another
+func (b *Bot) chargeGiveMessage(fromId, to, amount string) string {
+	points, err := strconv.Atoi(amount)
+	if err != nil || points < 1 {
+		return "Give how many points? Try `charge give @user 5`"
+	}
+	user, err := b.users.GetByUsername(context.TODO(), to)
+	if err != nil {
+		return "Who's " + to + "?"
+	}
+	if user.Id == fromId {
+		return "lol nice try"
+	}
+
+	now := b.clock.Now()
+	var left int
+	err = b.store.Update(func(tx store.Tx) error {
+		var err error
+		left, err = spendCharge(tx, chargeEvent{UserId: fromId, Delta: -points, At: now, Reason: chargeReasonGive, With: user.Id})
+		if err != nil {
+			return err
+		}
+		_, err = addCharge(tx, chargeEvent{UserId: user.Id, Delta: points, At: now, Reason: chargeReasonReceive, With: fromId})
+		return err
+	})
+	switch {
+	case errors.Is(err, errNotEnoughCharge):
+		return "You don't have " + strconv.Itoa(points) + " charge points to give :tensepepe:"
+	case err != nil:
+		zap.S().Error("Failed to give charge", zap.Error(err))
+		return "Couldn't give, my battery is dead :pepehands:"
+	}
+	return fmt.Sprintf("You gave %d charge points to @%s, %d left :hype:", points, user.Username, left)
+}
+
+// chargeBetMessage bets points on a coin flip: double or nothing
+func (b *Bot) chargeBetMessage(userId, amount string) string {
+	points, err := strconv.Atoi(amount)
+	if err != nil || points < 1 {
+		return "Bet how many points? Try `charge bet 5`"
+	}
+
+	won := false
+	var total int
+	err = b.store.Update(func(tx store.Tx) error {
+		// The stake is checked first so nobody bets points they don't have
+		var charge int
+		if _, err := tx.Get(chargeCollection, userId, &charge); err != nil {
+			return err
+		}
+		if charge < points {
+			return errNotEnoughCharge
+		}
+
+		won = b.random.Intn(2) == 1
+		delta := -points
+		if won {
+			delta = points
+		}
+		total, err = addCharge(tx, chargeEvent{UserId: userId, Delta: delta, At: b.clock.Now(), Reason: chargeReasonBet})
+		return err
+	})
+	switch {
+	case errors.Is(err, errNotEnoughCharge):
+		return "You don't have " + strconv.Itoa(points) + " charge points to bet :tensepepe:"
+	case err != nil:
+		zap.S().Error("Failed to bet charge", zap.Error(err))
+		return "Couldn't bet, my battery is dead :pepehands:"
+	case won:
+		return fmt.Sprintf("You won %d charge points, now at %d :musk:", points, total)
+	default:
+		return fmt.Sprintf("You lost %d charge points, down to %d :lamo:", points, total)
+	}
+}
+
+// chargeShopMessage lists the items for sale
+func (b *Bot) chargeShopMessage() string {
+	var sb strings.Builder
+	sb.WriteString("#### Charge shop\n| Item | Flair | Price |\n|:-----|:-----:|------:|\n")
+	for _, item := range shopItems {
+		fmt.Fprintf(&sb, "| %s | %s | %d |\n", item.name, item.flair, item.price)
+	}
+	sb.WriteString("\nBuy with `@" + b.user.Username + " charge buy <item>`, or wear an item you own again the same way.")
+	sb.WriteString(" Points reset after 4/20, but items are yours to keep.")
+	return sb.String()
+}
+
+// chargeBuyMessage buys an item, or wears it if the user already owns it
+func (b *Bot) chargeBuyMessage(userId, name string) string {
+	item := findShopItem(name)
+	if item == nil {
+		return "We don't sell " + name + " here. Try `charge shop`."
+	}
+
+	bought := false
+	var left int
+	err := b.store.Update(func(tx store.Tx) error {
+		var inventory []string
+		if _, err := tx.Get(chargeInventoryCollection, userId, &inventory); err != nil {
+			return err
+		}
+		if !containsFold(inventory, item.name) {
+			var err error
+			left, err = spendCharge(tx, chargeEvent{UserId: userId, Delta: -item.price, At: b.clock.Now(), Reason: chargeReasonShop, With: item.name})
+			if err != nil {
+				return err
+			}
+			if err := tx.Put(chargeInventoryCollection, userId, append(inventory, item.name)); err != nil {
+				return err
+			}
+			bought = true
+		}
+		return tx.Put(chargeFlairCollection, userId, item.name)
+	})
+	switch {
+	case errors.Is(err, errNotEnoughCharge):
+		return fmt.Sprintf("%s costs %d charge points, keep charging :tensepepe:", item.name, item.price)
+	case err != nil:
+		zap.S().Error("Failed to buy item", zap.Error(err))
+		return "Couldn't sell you that, my battery is dead :pepehands:"
+	case bought:
+		return fmt.Sprintf("You bought %s %s, %d charge points left", item.name, item.flair, left)
+	default:
+		return "You're wearing " + item.name + " " + item.flair + " again"
+	}
+}
+
+// userFlair returns the flair worn by a user, or an empty string
+func (b *Bot) userFlair(userId string) string {
+	var name string
+	if _, err := store.Get(b.store, chargeFlairCollection, userId, &name); err != nil || name == "" {
+		return ""
+	}
+	if item := findShopItem(name); item != nil {
+		return item.flair
+	}
+	return ""
+}
+
+// containsFold tells whether values has s, ignoring case
+func containsFold(values []string, s string) bool {
+	for _, value := range values {
+		if strings.EqualFold(value, s) {
+			return true
+		}
+	}
+	return false
+}
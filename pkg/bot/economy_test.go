@@ -0,0 +1,232 @@
+package bot
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/opendwellers/jujubot/pkg/config"
+	"github.com/opendwellers/jujubot/pkg/store"
+)
+
+// putCharge sets the charge points of users
+func putCharge(t *testing.T, b *Bot, balances map[string]int) {
+	t.Helper()
+	err := b.store.Update(func(tx store.Tx) error {
+		for userId, charge := range balances {
+			if err := tx.Put(chargeCollection, userId, charge); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// chargeEvents returns the audit trail, oldest first
+func chargeEvents(t *testing.T, b *Bot) []chargeEvent {
+	t.Helper()
+	var events []chargeEvent
+	err := b.store.View(func(tx store.Tx) error {
+		for _, key := range tx.Keys(chargeEventsCollection) {
+			var event chargeEvent
+			if _, err := tx.Get(chargeEventsCollection, key, &event); err != nil {
+				return err
+			}
+			events = append(events, event)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return events
+}
+
+func TestChargeGive(t *testing.T) {
+	tests := []struct {
+		name      string
+		to        string
+		amount    string
+		want      string
+		wantAlice int
+		wantBob   int
+	}{
+		{"give", "@bob", "5", "You gave 5 charge points to @bob, 5 left :hype:", 5, 5},
+		{"everything", "bob", "10", "You gave 10 charge points to @bob, 0 left :hype:", 0, 10},
+		{"not enough", "@bob", "11", "You don't have 11 charge points to give :tensepepe:", 10, 0},
+		{"to yourself", "@alice", "5", "lol nice try", 10, 0},
+		{"not a number", "@bob", "five", "Give how many points? Try `charge give @user 5`", 10, 0},
+		{"negative", "@bob", "-5", "Give how many points? Try `charge give @user 5`", 10, 0},
+		{"zero", "@bob", "0", "Give how many points? Try `charge give @user 5`", 10, 0},
+		{"unknown user", "@nobody", "5", "Who's @nobody?", 10, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := newTestBot(t, config.Config{})
+			putCharge(t, b, map[string]int{"alice": 10})
+
+			if got := b.chargeGiveMessage("alice", tt.to, tt.amount); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if alice, bob := b.getCharge("alice"), b.getCharge("bob"); alice != tt.wantAlice || bob != tt.wantBob {
+				t.Errorf("alice has %d and bob %d, want %d and %d", alice, bob, tt.wantAlice, tt.wantBob)
+			}
+		})
+	}
+}
+
+func TestChargeBet(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount string
+		// draw is the coin flip, 1 wins
+		draw int64
+		want string
+		// wantCharge is alice's charge afterwards, starting from 10
+		wantCharge int
+	}{
+		{"win", "4", 1, "You won 4 charge points, now at 14 :musk:", 14},
+		{"lose", "4", 0, "You lost 4 charge points, down to 6 :lamo:", 6},
+		{"all in", "10", 1, "You won 10 charge points, now at 20 :musk:", 20},
+		{"not enough", "11", 1, "You don't have 11 charge points to bet :tensepepe:", 10},
+		{"not a number", "lots", 1, "Bet how many points? Try `charge bet 5`", 10},
+		{"negative", "-4", 0, "Bet how many points? Try `charge bet 5`", 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := newTestBot(t, config.Config{}, WithRandom(NewScriptedRandom(tt.draw)))
+			putCharge(t, b, map[string]int{"alice": 10})
+
+			if got := b.chargeBetMessage("alice", tt.amount); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if got := b.getCharge("alice"); got != tt.wantCharge {
+				t.Errorf("charge is %d, want %d", got, tt.wantCharge)
+			}
+		})
+	}
+}
+
+func TestChargeBuy(t *testing.T) {
+	tests := []struct {
+		name      string
+		item      string
+		owned     []string
+		want      string
+		wantLeft  int
+		wantFlair string
+	}{
+		{"buy", "fire", nil, "You bought fire :fire:, 44 charge points left", 44, ":fire:"},
+		{"any case", "Crown", nil, "You bought crown :crown:, 0 charge points left", 0, ":crown:"},
+		{"not enough", "pogchampignon", nil, "pogchampignon costs 420 charge points, keep charging :tensepepe:", 69, ""},
+		{"unknown item", "yacht", nil, "We don't sell yacht here. Try `charge shop`.", 69, ""},
+		{"wear again", "leaf", []string{"leaf"}, "You're wearing leaf :herb: again", 69, ":herb:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := newTestBot(t, config.Config{})
+			putCharge(t, b, map[string]int{"alice": 69})
+			if tt.owned != nil {
+				if err := store.Put(b.store, chargeInventoryCollection, "alice", tt.owned); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if got := b.chargeBuyMessage("alice", tt.item); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if got := b.getCharge("alice"); got != tt.wantLeft {
+				t.Errorf("charge is %d, want %d", got, tt.wantLeft)
+			}
+			if got := b.userFlair("alice"); got != tt.wantFlair {
+				t.Errorf("flair is %q, want %q", got, tt.wantFlair)
+			}
+		})
+	}
+}
+
+func TestChargeConcurrentSpending(t *testing.T) {
+	b, _ := newTestBot(t, config.Config{})
+	putCharge(t, b, map[string]int{"alice": 100})
+
+	// Together they cost far more than alice has
+	var wg sync.WaitGroup
+	for range 40 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.chargeGiveMessage("alice", "@bob", "3")
+		}()
+	}
+	for _, item := range shopItems {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.chargeBuyMessage("alice", item.name)
+		}()
+	}
+	wg.Wait()
+
+	alice, bob := b.getCharge("alice"), b.getCharge("bob")
+	if alice < 0 {
+		t.Fatalf("alice is at %d points", alice)
+	}
+
+	// Replaying the audit trail gives back every balance
+	totals := map[string]int{"alice": 100}
+	spent := 0
+	for i, event := range chargeEvents(t, b) {
+		totals[event.UserId] += event.Delta
+		if event.Total != totals[event.UserId] {
+			t.Errorf("event %d of %s: total %d, want %d", i, event.UserId, event.Total, totals[event.UserId])
+		}
+		if event.Total < 0 {
+			t.Errorf("event %d: %s went down to %d", i, event.UserId, event.Total)
+		}
+		if event.UserId == "alice" {
+			spent -= event.Delta
+		}
+	}
+	if totals["alice"] != alice || totals["bob"] != bob {
+		t.Errorf("the events add up to %d and %d, but alice has %d and bob %d", totals["alice"], totals["bob"], alice, bob)
+	}
+	if spent != 100-alice {
+		t.Errorf("alice spent %d according to the events, want %d", spent, 100-alice)
+	}
+}
+
+func TestChargeSeasonResetsPoints(t *testing.T) {
+	b, _ := newTestBot(t, config.Config{})
+	putCharge(t, b, map[string]int{"alice": 100})
+	if err := store.Put(b.store, chargeMetaCollection, chargeLastSeasonKey, 2025); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.chargeBuyMessage("alice", "crown"); !strings.HasPrefix(got, "You bought crown") {
+		t.Fatalf("got %q, want crown bought", got)
+	}
+
+	b.archiveChargeSeason(time.Date(2026, time.April, 22, 12, 0, 0, 0, time.UTC))
+
+	// Unspent points are gone with the season, the crown is kept
+	if got := b.getCharge("alice"); got != 0 {
+		t.Errorf("charge is %d after the season, want 0", got)
+	}
+	if got := b.chargeGiveMessage("alice", "@bob", "1"); got != "You don't have 1 charge points to give :tensepepe:" {
+		t.Errorf("got %q giving after the season", got)
+	}
+	if got := b.userFlair("alice"); got != ":crown:" {
+		t.Errorf("flair is %q after the season, want :crown:", got)
+	}
+	var inventory []string
+	if _, err := store.Get(b.store, chargeInventoryCollection, "alice", &inventory); err != nil || !slices.Equal(inventory, []string{"crown"}) {
+		t.Errorf("inventory is %q after the season, want the crown", inventory)
+	}
+	if got := b.chargeBuyMessage("alice", "crown"); got != "You're wearing crown :crown: again" {
+		t.Errorf("got %q wearing the crown after the season", got)
+	}
+}
@@ -0,0 +1,267 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// FakeClient is an in-memory ChatClient that records everything the bot sends
+type FakeClient struct {
+	mu        sync.Mutex
+	me        *model.User
+	users     map[string]*model.User
+	teams     map[string]*model.Team
+	channels  map[string]*model.Channel
+	posts     []*model.Post
+	reactions []*model.Reaction
+	history   []*model.Post
+	events    chan *model.WebSocketEvent
+	pongs     chan struct{}
+}
+
+// NewFakeClient creates a FakeClient logged in as the given user
+func NewFakeClient(me *model.User) *FakeClient {
+	c := &FakeClient{
+		me:       me,
+		users:    make(map[string]*model.User),
+		teams:    make(map[string]*model.Team),
+		channels: make(map[string]*model.Channel),
+		events:   make(chan *model.WebSocketEvent, 100),
+		pongs:    make(chan struct{}, 1),
+	}
+	c.AddUser(me)
+	return c
+}
+
+// AddUser makes a user available through GetUser
+func (c *FakeClient) AddUser(user *model.User) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.users[user.Id] = user
+}
+
+// AddTeam makes a team available through GetTeamByName
+func (c *FakeClient) AddTeam(team *model.Team) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.teams[team.Name] = team
+}
+
+// AddChannel makes a channel available through GetChannelByName
+func (c *FakeClient) AddChannel(channel *model.Channel) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.channels[channel.TeamId+"/"+channel.Name] = channel
+}
+
+// AddHistory records a post that GetPostsSince and GetPostsForChannel will
+// return, as if it had been posted while the bot was disconnected
+func (c *FakeClient) AddHistory(post *model.Post) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.history = append(c.history, post)
+}
+
+// Emit queues an event on the event stream
+func (c *FakeClient) Emit(event *model.WebSocketEvent) {
+	c.events <- event
+}
+
+// EmitPost queues a posted event for the given post
+func (c *FakeClient) EmitPost(post *model.Post) error {
+	event, err := newPostedEvent(post)
+	if err != nil {
+		return err
+	}
+	c.Emit(event)
+	return nil
+}
+
+// Posts returns a copy of every post created so far
+func (c *FakeClient) Posts() []*model.Post {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*model.Post(nil), c.posts...)
+}
+
+// Reactions returns a copy of every reaction saved so far
+func (c *FakeClient) Reactions() []*model.Reaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*model.Reaction(nil), c.reactions...)
+}
+
+// Reset forgets all recorded posts and reactions
+func (c *FakeClient) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.posts = nil
+	c.reactions = nil
+}
+
+func (c *FakeClient) GetMe(_ context.Context) (*model.User, error) {
+	return c.me, nil
+}
+
+func (c *FakeClient) GetUser(_ context.Context, userId string) (*model.User, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if user, ok := c.users[userId]; ok {
+		return user, nil
+	}
+	return nil, errors.New("user not found: " + userId)
+}
+
+func (c *FakeClient) GetUserByUsername(_ context.Context, username string) (*model.User, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, user := range c.users {
+		if strings.EqualFold(user.Username, username) {
+			return user, nil
+		}
+	}
+	return nil, errors.New("user not found: " + username)
+}
+
+func (c *FakeClient) GetTeamByName(_ context.Context, name string) (*model.Team, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if team, ok := c.teams[name]; ok {
+		return team, nil
+	}
+	return nil, errors.New("team not found: " + name)
+}
+
+func (c *FakeClient) GetChannelByName(_ context.Context, name, teamId string) (*model.Channel, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if channel, ok := c.channels[teamId+"/"+name]; ok {
+		return channel, nil
+	}
+	return nil, errors.New("channel not found: " + name)
+}
+
+func (c *FakeClient) CreateChannel(_ context.Context, channel *model.Channel) (*model.Channel, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	created := channel.DeepCopy()
+	if created.Id == "" {
+		created.Id = model.NewId()
+	}
+	c.channels[created.TeamId+"/"+created.Name] = created
+	return created, nil
+}
+
+func (c *FakeClient) CreateDirectChannel(_ context.Context, userId1, userId2 string) (*model.Channel, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	name := model.GetDMNameFromIds(userId1, userId2)
+	if channel, ok := c.channels["/"+name]; ok {
+		return channel, nil
+	}
+	channel := &model.Channel{Id: model.NewId(), Name: name, Type: model.ChannelTypeDirect}
+	c.channels["/"+name] = channel
+	return channel, nil
+}
+
+func (c *FakeClient) CreatePost(_ context.Context, post *model.Post) (*model.Post, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	created := post.Clone()
+	if created.Id == "" {
+		created.Id = model.NewId()
+	}
+	if created.UserId == "" {
+		created.UserId = c.me.Id
+	}
+	c.posts = append(c.posts, created)
+	return created, nil
+}
+
+func (c *FakeClient) SaveReaction(_ context.Context, reaction *model.Reaction) (*model.Reaction, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	saved := *reaction
+	c.reactions = append(c.reactions, &saved)
+	return &saved, nil
+}
+
+func (c *FakeClient) GetChannelsForUser(_ context.Context, teamId, _ string) ([]*model.Channel, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var channels []*model.Channel
+	for _, channel := range c.channels {
+		if channel.TeamId == teamId {
+			channels = append(channels, channel)
+		}
+	}
+	return channels, nil
+}
+
+func (c *FakeClient) GetPostsSince(_ context.Context, channelId string, since int64) ([]*model.Post, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var posts []*model.Post
+	for _, post := range c.history {
+		if post.ChannelId == channelId && post.CreateAt > since {
+			posts = append(posts, post)
+		}
+	}
+	return posts, nil
+}
+
+func (c *FakeClient) GetPostsForChannel(_ context.Context, channelId string, page, perPage int) ([]*model.Post, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var posts []*model.Post
+	for _, post := range c.history {
+		if post.ChannelId == channelId {
+			posts = append(posts, post)
+		}
+	}
+	sort.SliceStable(posts, func(i, j int) bool {
+		return posts[i].CreateAt > posts[j].CreateAt
+	})
+
+	start := min(page*perPage, len(posts))
+	end := min(start+perPage, len(posts))
+	return posts[start:end], nil
+}
+
+// Connect returns a stream fed by Emit and EmitPost
+func (c *FakeClient) Connect() (EventStream, error) {
+	return &fakeStream{events: c.events, pongs: c.pongs}, nil
+}
+
+// fakeStream is the EventStream returned by FakeClient. It answers every
+// ping immediately.
+type fakeStream struct {
+	events chan *model.WebSocketEvent
+	pongs  chan struct{}
+}
+
+func (s *fakeStream) Events() <-chan *model.WebSocketEvent {
+	return s.events
+}
+
+func (s *fakeStream) Ping() {
+	select {
+	case s.pongs <- struct{}{}:
+	default:
+	}
+}
+
+func (s *fakeStream) Pongs() <-chan struct{} {
+	return s.pongs
+}
+
+func (s *fakeStream) Err() error {
+	return nil
+}
+
+func (s *fakeStream) Close() {}
@@ -1,26 +1,40 @@
 package bot
 
 import (
-	"context"
 	"encoding/json"
-	"math/rand"
 	"strings"
 
 	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/opendwellers/jujubot/pkg/normalize"
 	"go.uber.org/zap"
 )
 
 // handleEvent routes WebSocket events to appropriate handlers
 func (b *Bot) handleEvent(event *model.WebSocketEvent) {
 	zap.S().Debug("Got event: ", event)
+	eventsTotal.Inc(string(event.EventType()))
 
-	// Only handle posted messages
-	if event.EventType() != model.WebsocketEventPosted {
+	switch event.EventType() {
+	case model.WebsocketEventPosted:
+	case model.WebsocketEventUserUpdated:
+		b.users.handleUserUpdated(event)
+		return
+	default:
+		return
+	}
+
+	data, ok := event.GetData()["post"].(string)
+	if !ok {
 		return
 	}
 
 	var post *model.Post
-	if err := json.NewDecoder(strings.NewReader(event.GetData()["post"].(string))).Decode(&post); err != nil || post == nil {
+	if err := json.NewDecoder(strings.NewReader(data)).Decode(&post); err != nil || post == nil {
+		return
+	}
+
+	// Skip posts already handled, e.g. replayed during catch-up
+	if !b.conn.markSeen(post) {
 		return
 	}
 
@@ -29,29 +43,38 @@ func (b *Bot) handleEvent(event *model.WebSocketEvent) {
 		return
 	}
 
+	// The sender name comes with live events, saving a user lookup
+	sender, ok := event.GetData()["sender_name"].(string)
+	if !ok {
+		sender = post.UserId
+	}
+	zap.S().Info("Processing message from user ", sender, ": ", post.Message)
+
+	b.learn(post)
 	b.handleMessage(post)
 }
 
 // handleMessage processes an incoming message
 func (b *Bot) handleMessage(post *model.Post) {
-	user, _, err := b.client.GetUser(context.TODO(), post.UserId, "")
-	if err != nil {
-		zap.S().Info("Failed to get user ", post.UserId, zap.Error(err))
-	}
-	zap.S().Info("Processing message from user ", user.Username, ": ", post.Message)
-
 	replyToId := post.RootId
 
-	// Check for named commands first (messages starting with @botname)
+	// Check for named commands first (messages starting with @botname).
+	// They get the raw text.
 	if b.handleNamedCommands(post, replyToId) {
 		return
 	}
 
-	// Then check for pattern-based reactions
-	b.handlePatternReactions(post, replyToId)
+	// Then check for pattern-based reactions, ignoring accents, code, quotes
+	// and links
+	if b.handlePatternReactions(post, normalize.Message(post.Message), replyToId) {
+		return
+	}
+
+	// Finally, sometimes chime in
+	b.interject(post, replyToId)
 }
 
 // randomChoice returns a random element from a slice
-func randomChoice(choices []string) string {
-	return choices[rand.Intn(len(choices))]
+func (b *Bot) randomChoice(choices []string) string {
+	return choices[b.random.Intn(len(choices))]
 }
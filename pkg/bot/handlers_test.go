@@ -0,0 +1,129 @@
+package bot
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/opendwellers/jujubot/pkg/config"
+	"github.com/opendwellers/jujubot/pkg/store"
+)
+
+func TestNamedCommands(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    []string
+	}{
+		{"command", "@jujubot I love you", []string{"@alice: <3"}},
+		{"keyword ignores case", "@jujubot i LOVE you", []string{"@alice: <3"}},
+		{"alias", "@jujubot merci", []string{"@alice: de rien la"}},
+		{"multi-word alias", "@jujubot ta yeule", []string{"@alice: no u?"}},
+		{"arguments", "@jujubot est-ce que ca marche?", []string{"@alice: maybe"}},
+		{"unknown command", "@jujubot dance", []string{"Kes tu. Veux???? Try `@jujubot help`."}},
+		{"unexpected arguments", "@jujubot I love you too", []string{"Kes tu. Veux???? Try `@jujubot help`."}},
+		{"keyword must be a whole word", "@jujubot thanksgiving", []string{"Kes tu. Veux???? Try `@jujubot help`."}},
+		{"help on unknown command", "@jujubot help dance", []string{"@alice: No command named `dance`. Try `@jujubot help`."}},
+		{"mention not at the start", "hey @jujubot I love you", nil},
+		{"other user mentioned", "@bob I love you", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, client := newTestBot(t, config.Config{})
+			say(b, "alice", tt.message)
+			if got := postedMessages(client); !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNamedCommandsConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands map[string]config.CommandConfig
+		want     string
+	}{
+		{"enabled", nil, "@alice: <3"},
+		{"disabled", map[string]config.CommandConfig{"i love you": {Disabled: true}}, "Kes tu. Veux???? Try `@jujubot help`."},
+		{"disabled in channel", map[string]config.CommandConfig{"i love you": {DisabledChannels: []string{testChannelId}}}, "Kes tu. Veux???? Try `@jujubot help`."},
+		{"other channel only", map[string]config.CommandConfig{"i love you": {Channels: []string{"elsewhere"}}}, "Kes tu. Veux???? Try `@jujubot help`."},
+		{"this channel only", map[string]config.CommandConfig{"i love you": {Channels: []string{testChannelId}}}, "@alice: <3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, client := newTestBot(t, config.Config{Commands: tt.commands})
+			say(b, "alice", "@jujubot I love you")
+			if got := postedMessages(client); !slices.Equal(got, []string{tt.want}) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPatternReactions(t *testing.T) {
+	tests := []struct {
+		name          string
+		message       string
+		wantPosts     []string
+		wantReactions []string
+	}{
+		{"reply", "salut tout le monde", []string{"@alice: aaaaaaayyeee"}, nil},
+		{"post", "je fais du velo en hiver", []string{"wow cest fukin dangereux faut vraiment etre retarded pour cycler en hiver (dans une tempete de verglas) :huel:"}, nil},
+		{"accents are ignored", "Je fais du vélo en HIVER", []string{"wow cest fukin dangereux faut vraiment etre retarded pour cycler en hiver (dans une tempete de verglas) :huel:"}, nil},
+		{"capture group", "xddd", []string{"haha xddd"}, nil},
+		{"emoji only", "lol ;)", nil, []string{"wink"}},
+		{"post with emoji", "this", []string{"this"}, []string{"point_up_2"}},
+		{"emoji stack with text", "salut ;) :P", []string{"@alice: aaaaaaayyeee"}, []string{"wink", "stuck_out_tongue"}},
+		{"one text reaction per message", "salut, bon matin", []string{"@alice: aaaaaaayyeee"}, nil},
+		{"built-in reaction wins a tie", "salut xd", []string{"haha xd"}, nil},
+		{"code is ignored", "`salut`", nil, nil},
+		{"no match", "rien de special", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, client := newTestBot(t, config.Config{})
+			say(b, "alice", tt.message)
+			if got := postedMessages(client); !slices.Equal(got, tt.wantPosts) {
+				t.Errorf("posts: got %q, want %q", got, tt.wantPosts)
+			}
+			if got := savedReactions(client); !slices.Equal(got, tt.wantReactions) {
+				t.Errorf("reactions: got %q, want %q", got, tt.wantReactions)
+			}
+		})
+	}
+}
+
+func TestCreateReply(t *testing.T) {
+	tests := []struct {
+		name   string
+		userId string
+		flair  string
+		want   string
+	}{
+		{"known user", "alice", "", "@alice: hi"},
+		{"unknown user", "nobody", "", "@unknown: hi"},
+		{"with flair", "bob", "crown", "@bob :crown:: hi"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, client := newTestBot(t, config.Config{})
+			if tt.flair != "" {
+				if err := store.Put(b.store, chargeFlairCollection, tt.userId, tt.flair); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			b.createReply(testChannelId, "hi", "root", tt.userId)
+			posts := client.Posts()
+			if len(posts) != 1 {
+				t.Fatalf("got %d posts, want 1", len(posts))
+			}
+			if posts[0].Message != tt.want {
+				t.Errorf("got %q, want %q", posts[0].Message, tt.want)
+			}
+			if posts[0].RootId != "root" || posts[0].ChannelId != testChannelId {
+				t.Errorf("posted in %s/%s, want %s/root", posts[0].ChannelId, posts[0].RootId, testChannelId)
+			}
+		})
+	}
+}
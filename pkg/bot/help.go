@@ -0,0 +1,65 @@
+package bot
+
+import (
+	"strings"
+)
+
+// handleHelpCommand lists every command, or details a single one
+func (b *Bot) handleHelpCommand(req *CommandRequest) {
+	post := req.Post
+
+	if name := strings.TrimSpace(req.Args[1]); name != "" {
+		cmd := b.commands.Lookup(name)
+		if cmd == nil || !b.commandEnabled(cmd, post.ChannelId) {
+			b.createReply(post.ChannelId, "No command named `"+name+"`. Try `@"+b.user.Username+" help`.", req.ReplyToId, post.UserId)
+			return
+		}
+		b.createPost(post.ChannelId, b.commandHelp(cmd), req.ReplyToId)
+		return
+	}
+
+	b.createPost(post.ChannelId, b.commandList(post.ChannelId), req.ReplyToId)
+}
+
+// commandList returns a one-line description of every command enabled in the channel
+func (b *Bot) commandList(channelId string) string {
+	var sb strings.Builder
+	sb.WriteString("#### Commands\n")
+	for _, cmd := range b.commands.Commands() {
+		if !b.commandEnabled(cmd, channelId) {
+			continue
+		}
+		sb.WriteString("- `" + cmd.Name + "` " + cmd.Description)
+		if cmd.Only420 {
+			sb.WriteString(" _(4/20 only)_")
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\nUse `@" + b.user.Username + " help <command>` for details.")
+	return sb.String()
+}
+
+// commandHelp returns the usage, aliases and examples of a command
+func (b *Bot) commandHelp(cmd *Command) string {
+	mention := "@" + b.user.Username + " "
+
+	var sb strings.Builder
+	sb.WriteString("#### " + cmd.Name + "\n" + cmd.Description + "\n\n")
+	sb.WriteString("**Usage:** `" + mention + cmd.Usage + "`\n")
+	if len(cmd.Aliases) > 0 {
+		sb.WriteString("**Aliases:** `" + strings.Join(cmd.Aliases, "`, `") + "`\n")
+	}
+	if cmd.Only420 {
+		sb.WriteString("**Only available on April 20th**\n")
+	}
+	if cmd.AdminOnly {
+		sb.WriteString("**Admins only**\n")
+	}
+	if len(cmd.Examples) > 0 {
+		sb.WriteString("**Examples:**\n")
+		for _, example := range cmd.Examples {
+			sb.WriteString("- `" + mention + example + "`\n")
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
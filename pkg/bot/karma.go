@@ -0,0 +1,181 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/opendwellers/jujubot/pkg/config"
+	"github.com/opendwellers/jujubot/pkg/store"
+	"go.uber.org/zap"
+)
+
+const (
+	// karmaCollection stores the karma of each thing, keyed by karmaKey
+	karmaCollection = "karma"
+	// karmaTopSize is the number of things shown by karma top
+	karmaTopSize = 10
+)
+
+// karmaReaction counts thing++ and thing-- in messages. It comes before the
+// other reactions since it keeps score. Things have at least 2 characters so
+// "C++" isn't karma, and the character after ++ or -- is checked by
+// karmaEnds.
+var karmaReaction = patternReaction{
+	name:        "karma",
+	pattern:     `(?:^|[\s(,;])(@?[\pL\pN_][\pL\pN_.\-]+?)(\+\+|--)([^\s(,;]?)`,
+	useSubmatch: true,
+	priority:    100,
+	handler:     (*Bot).handleKarma,
+}
+
+// handleKarma applies every karma change of a message and answers with the
+// new totals. Users can't change their own karma, and can only change the
+// karma of a thing once per karma cooldown.
+func (b *Bot) handleKarma(post *model.Post, replyToId, _ string, matched [][]string) bool {
+	var author string
+	if user, err := b.users.Get(context.TODO(), post.UserId); err == nil {
+		author = strings.ToLower(user.Username)
+	}
+
+	var lines []string
+	seen := make(map[string]bool)
+	for _, m := range matched {
+		if !karmaEnds(m[3]) {
+			continue
+		}
+		thing := b.karmaKey(context.TODO(), m[1])
+		if seen[thing] {
+			continue
+		}
+		seen[thing] = true
+
+		if thing == author {
+			lines = append(lines, "lol nice try, no self-karma")
+			continue
+		}
+		if !b.limiter.allow("karma:"+post.UserId+":"+thing, "", config.ReactionLimit{Cooldown: &b.config.KarmaCooldown}) {
+			continue
+		}
+
+		delta := 1
+		if m[2] == "--" {
+			delta = -1
+		}
+		total, err := b.addKarma(thing, delta)
+		if err != nil {
+			zap.S().Error("Failed to save karma", zap.Error(err))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s: %d karma", thing, total))
+	}
+
+	if len(lines) == 0 {
+		return false
+	}
+	b.createPost(post.ChannelId, strings.Join(lines, "\n"), replyToId)
+	return true
+}
+
+// karmaEnds tells whether the character after ++ or -- ends the karma
+// change, as in "poutine++!" but not "thanks--sorry" or "a+++"
+func karmaEnds(next string) bool {
+	if next == "" {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(next)
+	return unicode.IsPunct(r) && r != '-' && r != '_'
+}
+
+// karmaKey returns the key the karma of a thing is stored under, its
+// lowercase name. Users have the same key with or without the @: their
+// username.
+func (b *Bot) karmaKey(ctx context.Context, thing string) string {
+	name, mention := strings.CutPrefix(strings.ToLower(thing), "@")
+	if mention {
+		if user, err := b.users.GetByUsername(ctx, name); err == nil {
+			return strings.ToLower(user.Username)
+		}
+	}
+	return name
+}
+
+// addKarma changes the karma of a thing and returns its new total
+func (b *Bot) addKarma(thing string, delta int) (int, error) {
+	var karma int
+	err := b.store.Update(func(tx store.Tx) error {
+		if _, err := tx.Get(karmaCollection, thing, &karma); err != nil {
+			return err
+		}
+		karma += delta
+		return tx.Put(karmaCollection, thing, karma)
+	})
+	return karma, err
+}
+
+// karmaEntry is the karma of a thing
+type karmaEntry struct {
+	thing string
+	karma int
+}
+
+// topKarma returns the things with the most karma
+func (b *Bot) topKarma(n int) ([]karmaEntry, error) {
+	var entries []karmaEntry
+	err := b.store.View(func(tx store.Tx) error {
+		for _, thing := range tx.Keys(karmaCollection) {
+			var karma int
+			if _, err := tx.Get(karmaCollection, thing, &karma); err != nil {
+				return err
+			}
+			entries = append(entries, karmaEntry{thing: thing, karma: karma})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].karma > entries[j].karma
+	})
+	return entries[:min(n, len(entries))], nil
+}
+
+// handleKarmaCommand shows the karma of a thing, or the leaderboard
+func (b *Bot) handleKarmaCommand(req *CommandRequest) {
+	post := req.Post
+	thing := strings.ToLower(req.Args[1])
+
+	if thing != "top" {
+		thing = b.karmaKey(context.TODO(), thing)
+		var karma int
+		if _, err := store.Get(b.store, karmaCollection, thing, &karma); err != nil {
+			zap.S().Error("Failed to load karma", zap.Error(err))
+			return
+		}
+		b.createPost(post.ChannelId, fmt.Sprintf("%s: %d karma", thing, karma), req.ReplyToId)
+		return
+	}
+
+	entries, err := b.topKarma(karmaTopSize)
+	if err != nil {
+		zap.S().Error("Failed to load karma", zap.Error(err))
+		return
+	}
+	if len(entries) == 0 {
+		b.createPost(post.ChannelId, "Nobody has any karma yet. Try `thing++`.", req.ReplyToId)
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("#### Karma\n")
+	for i, entry := range entries {
+		fmt.Fprintf(&sb, "%d. %s (%d)\n", i+1, entry.thing, entry.karma)
+	}
+	b.createPost(post.ChannelId, sb.String(), req.ReplyToId)
+}
@@ -0,0 +1,61 @@
+package bot
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/opendwellers/jujubot/pkg/config"
+)
+
+func TestKarma(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []string
+		want     []string
+	}{
+		{"plus", []string{"poutine++"}, []string{"poutine: 1 karma"}},
+		{"minus", []string{"lundi--"}, []string{"lundi: -1 karma"}},
+		{"case is ignored", []string{"Poutine++", "POUTINE++"}, []string{"poutine: 1 karma", "poutine: 2 karma"}},
+		{"several things", []string{"poutine++ lundi-- (vendredi++)"}, []string{"poutine: 1 karma\nlundi: -1 karma\nvendredi: 1 karma"}},
+		{"lists", []string{"poutine++,frites++"}, []string{"poutine: 1 karma\nfrites: 1 karma"}},
+		{"in a sentence", []string{"merci bob++ t'es le meilleur"}, []string{"bob: 1 karma"}},
+		{"punctuation after", []string{"poutine++!", "lundi--."}, []string{"poutine: 1 karma", "lundi: -1 karma"}},
+		{"text after", []string{"thanks--sorry", "a++b"}, nil},
+		{"more signs", []string{"poutine+++", "lundi---"}, nil},
+		{"single character", []string{"C++ is great", "i++"}, nil},
+		{"inside a word", []string{"foo+bar++"}, nil},
+		{"in code", []string{"`i++`"}, nil},
+		{"user with and without @", []string{"@bob++", "bob++", "@BOB++"}, []string{"bob: 1 karma", "bob: 2 karma", "bob: 3 karma"}},
+		{"unknown mention", []string{"@poutine++", "poutine++"}, []string{"poutine: 1 karma", "poutine: 2 karma"}},
+		{"same thing once per message", []string{"bob++ @bob++"}, []string{"bob: 1 karma"}},
+		{"self karma", []string{"alice++", "@alice++"}, []string{"lol nice try, no self-karma", "lol nice try, no self-karma"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, client := newTestBot(t, config.Config{})
+			for _, message := range tt.messages {
+				say(b, "alice", message)
+			}
+			if got := postedMessages(client); !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKarmaCommand(t *testing.T) {
+	b, client := newTestBot(t, config.Config{})
+	say(b, "alice", "@bob++ poutine++")
+	say(b, "bob", "poutine++")
+	client.Reset()
+
+	say(b, "alice", "@jujubot karma @bob")
+	say(b, "alice", "@jujubot karma Bob")
+	say(b, "alice", "@jujubot karma lundi")
+	say(b, "alice", "@jujubot karma top")
+
+	want := []string{"bob: 1 karma", "bob: 1 karma", "lundi: 0 karma", "#### Karma\n1. poutine (2)\n2. bob (1)\n"}
+	if got := postedMessages(client); !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
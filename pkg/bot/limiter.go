@@ -0,0 +1,93 @@
+package bot
+
+import (
+	"sync"
+	"time"
+
+	"github.com/opendwellers/jujubot/pkg/config"
+)
+
+// limitAll is the reaction name whose configured limits apply to every reaction
+const limitAll = "*"
+
+// limiter decides whether a reaction may fire in a channel, given its
+// cooldown, probability and daily cap. It is safe for concurrent use.
+type limiter struct {
+	now    func() time.Time
+	random func() float64
+
+	mu        sync.Mutex
+	lastFired map[string]time.Time
+	daily     map[string]dailyCount
+}
+
+// dailyCount is how many times a reaction fired on a given day
+type dailyCount struct {
+	day   string
+	count int
+}
+
+// newLimiter creates a limiter reading the time from now and drawing
+// probabilities from random, which returns a number in [0, 1)
+func newLimiter(now func() time.Time, random func() float64) *limiter {
+	return &limiter{
+		now:       now,
+		random:    random,
+		lastFired: make(map[string]time.Time),
+		daily:     make(map[string]dailyCount),
+	}
+}
+
+// allow reports whether the reaction identified by key may fire in the
+// channel, and records it as fired if so
+func (l *limiter) allow(key, channelId string, limit config.ReactionLimit) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key += "\x00" + channelId
+	now := l.now()
+
+	if last, ok := l.lastFired[key]; ok && limit.Cooldown != nil && now.Sub(last) < *limit.Cooldown {
+		return false
+	}
+
+	today := now.Format(time.DateOnly)
+	count := l.daily[key]
+	if count.day != today {
+		count = dailyCount{day: today}
+	}
+	if limit.DailyCap != nil && *limit.DailyCap > 0 && count.count >= *limit.DailyCap {
+		return false
+	}
+
+	if p := limit.Probability; p != nil && *p < 1 && (*p <= 0 || l.random() >= *p) {
+		return false
+	}
+
+	l.lastFired[key] = now
+	count.count++
+	l.daily[key] = count
+	return true
+}
+
+// reactionLimit returns the limits of a reaction in a channel: the configured
+// ones for the reaction, then the ones for every reaction, then its own
+func (b *Bot) reactionLimit(name, channelId string, limit config.ReactionLimit) config.ReactionLimit {
+	overrides := b.config.ReactionLimits[channelId]
+	for _, override := range []string{limitAll, name} {
+		o, ok := overrides[override]
+		if !ok {
+			continue
+		}
+		if o.Cooldown != nil {
+			limit.Cooldown = o.Cooldown
+		}
+		if o.Probability != nil {
+			limit.Probability = o.Probability
+		}
+		if o.DailyCap != nil {
+			limit.DailyCap = o.DailyCap
+		}
+	}
+	return limit
+}
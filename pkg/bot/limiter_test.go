@@ -0,0 +1,139 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/opendwellers/jujubot/pkg/config"
+)
+
+func TestLimiterCooldown(t *testing.T) {
+	clock := NewFakeClock(testNow)
+	l := newLimiter(clock.Now, NewScriptedRandom().Float64)
+	limit := config.ReactionLimit{Cooldown: new(10 * time.Minute)}
+
+	steps := []struct {
+		advance   time.Duration
+		channelId string
+		want      bool
+	}{
+		{0, "a", true},
+		{0, "a", false},
+		{0, "b", true},
+		{9*time.Minute + 59*time.Second, "a", false},
+		{time.Second, "a", true},
+		{time.Second, "a", false},
+	}
+	for i, step := range steps {
+		clock.Advance(step.advance)
+		if got := l.allow("greeting", step.channelId, limit); got != step.want {
+			t.Errorf("step %d: got %v, want %v", i, got, step.want)
+		}
+	}
+}
+
+func TestLimiterDailyCap(t *testing.T) {
+	clock := NewFakeClock(time.Date(2026, time.January, 15, 23, 59, 0, 0, time.UTC))
+	l := newLimiter(clock.Now, NewScriptedRandom().Float64)
+	limit := config.ReactionLimit{DailyCap: new(2)}
+
+	steps := []struct {
+		advance time.Duration
+		want    bool
+	}{
+		{0, true},
+		{0, true},
+		{0, false},
+		{59 * time.Second, false},
+		// Midnight
+		{time.Second, true},
+		{0, true},
+		{0, false},
+	}
+	for i, step := range steps {
+		clock.Advance(step.advance)
+		if got := l.allow("xd", testChannelId, limit); got != step.want {
+			t.Errorf("step %d at %s: got %v, want %v", i, clock.Now().Format(time.TimeOnly), got, step.want)
+		}
+	}
+}
+
+func TestLimiterProbability(t *testing.T) {
+	tests := []struct {
+		name        string
+		probability *float64
+		draws       []float64
+		want        []bool
+	}{
+		{"unset always fires", nil, nil, []bool{true, true, true}},
+		{"1 always fires", new(1.0), nil, []bool{true, true, true}},
+		{"0 never fires", new(0.0), []float64{0, 0, 0}, []bool{false, false, false}},
+		{"fires below the probability", new(0.25), []float64{0.1, 0.25, 0.9, 0.24}, []bool{true, false, false, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			random := NewScriptedRandom()
+			random.PushFloats(tt.draws...)
+			l := newLimiter(NewFakeClock(testNow).Now, random.Float64)
+			for i, want := range tt.want {
+				if got := l.allow("xd", testChannelId, config.ReactionLimit{Probability: tt.probability}); got != want {
+					t.Errorf("draw %d: got %v, want %v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestReactionLimitOverrides(t *testing.T) {
+	b, _ := newTestBot(t, config.Config{ReactionLimits: map[string]map[string]config.ReactionLimit{
+		testChannelId: {
+			limitAll: {Cooldown: new(time.Minute), DailyCap: new(5)},
+			"xd":     {Probability: new(0.0)},
+		},
+	}})
+	own := config.ReactionLimit{Cooldown: new(time.Hour), Probability: new(0.5)}
+
+	got := b.reactionLimit("xd", testChannelId, own)
+	if *got.Cooldown != time.Minute || *got.DailyCap != 5 || got.Probability == nil || *got.Probability != 0 {
+		t.Errorf("xd: got %+v, want a 1m cooldown, a cap of 5 and a probability of 0", got)
+	}
+	got = b.reactionLimit("greeting", testChannelId, own)
+	if *got.Cooldown != time.Minute || got.Probability == nil || *got.Probability != 0.5 {
+		t.Errorf("greeting: got %+v, want a 1m cooldown and its own probability", got)
+	}
+	if got = b.reactionLimit("xd", "elsewhere", own); *got.Cooldown != time.Hour || got.DailyCap != nil || *got.Probability != 0.5 {
+		t.Errorf("other channel: got %+v, want the reaction's own limits", got)
+	}
+}
+
+func TestReactionLimitOverrideLiftsLimits(t *testing.T) {
+	b, client := newTestBot(t, config.Config{ReactionLimits: map[string]map[string]config.ReactionLimit{
+		testChannelId: {
+			limitAll: {DailyCap: new(1)},
+			"xd":     {Cooldown: new(time.Duration(0)), DailyCap: new(0)},
+		},
+	}})
+
+	got := b.reactionLimit("xd", testChannelId, config.ReactionLimit{Cooldown: new(10 * time.Minute)})
+	if *got.Cooldown != 0 || *got.DailyCap != 0 {
+		t.Errorf("got a %v cooldown and a cap of %d, want neither", *got.Cooldown, *got.DailyCap)
+	}
+
+	// The built-in xd reaction has a 10 minute cooldown
+	for range 3 {
+		say(b, "alice", "xd")
+	}
+	if got := postedMessages(client); len(got) != 3 {
+		t.Errorf("got %q, want 3 answers", got)
+	}
+}
+
+func TestReactionDisabledInChannel(t *testing.T) {
+	b, client := newTestBot(t, config.Config{ReactionLimits: map[string]map[string]config.ReactionLimit{
+		testChannelId: {"xd": {Probability: new(0.0)}},
+	}})
+	say(b, "alice", "xddd")
+	if got := postedMessages(client); len(got) != 0 {
+		t.Errorf("got %q, want nothing", got)
+	}
+}
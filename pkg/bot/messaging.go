@@ -15,14 +15,18 @@ func (b *Bot) createPost(channelId, message, replyToId string) {
 		RootId:    replyToId,
 	}
 
-	if _, _, err := b.client.CreatePost(context.TODO(), post); err != nil {
+	if _, err := b.client.CreatePost(context.TODO(), post); err != nil {
 		zap.S().Error("Failed to send message", zap.Error(err))
 	}
 }
 
-// createReply creates a reply mentioning a specific user
+// createReply creates a reply mentioning a specific user, followed by the
+// flair they bought
 func (b *Bot) createReply(channelId, message, replyToId, replyToUserId string) {
 	mention := b.getUserMention(replyToUserId)
+	if flair := b.userFlair(replyToUserId); flair != "" {
+		mention += " " + flair
+	}
 	b.createPost(channelId, mention+": "+message, replyToId)
 }
 
@@ -34,14 +38,14 @@ func (b *Bot) createReaction(emojiName, postId string) {
 		EmojiName: emojiName,
 	}
 
-	if _, _, err := b.client.SaveReaction(context.TODO(), reaction); err != nil {
+	if _, err := b.client.SaveReaction(context.TODO(), reaction); err != nil {
 		zap.S().Error("Failed to add reaction", zap.Error(err))
 	}
 }
 
 // getUserMention returns the @mention string for a user
 func (b *Bot) getUserMention(userId string) string {
-	user, _, err := b.client.GetUser(context.TODO(), userId, "")
+	user, err := b.users.Get(context.TODO(), userId)
 	if err != nil {
 		zap.S().Error("Failed to get user", zap.Error(err))
 		return "@unknown"
@@ -0,0 +1,18 @@
+package bot
+
+import "github.com/opendwellers/jujubot/pkg/metrics"
+
+var (
+	eventsTotal = metrics.NewCounterVec("jujubot_websocket_events_total",
+		"WebSocket events received, by event type.", "type")
+	commandsTotal = metrics.NewCounterVec("jujubot_commands_total",
+		"Named commands handled, by command.", "command")
+	unknownCommandsTotal = metrics.NewCounterVec("jujubot_unknown_commands_total",
+		"Named commands that matched no handler.")
+	reactionsTotal = metrics.NewCounterVec("jujubot_reactions_total",
+		"Pattern reactions fired, by reaction.", "reaction")
+	eventQueueDepth = metrics.NewGaugeVec("jujubot_event_queue_depth",
+		"Events waiting to be handled, by worker.", "worker")
+	eventQueueFullTotal = metrics.NewCounterVec("jujubot_event_queue_full_total",
+		"Times the WebSocket reader had to wait for a full worker queue, by worker.", "worker")
+)
@@ -1,165 +1,71 @@
 package bot
 
 import (
-	"math/rand"
 	"regexp"
+	"slices"
+	"sort"
+	"time"
 
 	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/opendwellers/jujubot/pkg/config"
+	"github.com/pkg/errors"
 )
 
 const globalRegexOptions = "(?i)"
 
-// patternReaction defines a pattern and its response
+// reactionClass tells how reactions to the same message combine
+type reactionClass int
+
+const (
+	// classText reactions post a message. Only one answers a message.
+	classText reactionClass = iota
+	// classEmoji reactions only add emoji. They all stack.
+	classEmoji
+)
+
+// patternReaction defines a pattern and its response. The handler gets the
+// normalized text the pattern matched, and its submatches with useSubmatch.
 type patternReaction struct {
+	name        string
 	pattern     string
-	handler     func(b *Bot, post *model.Post, replyToId string, matched [][]string) bool
+	handler     func(b *Bot, post *model.Post, replyToId, text string, matched [][]string) bool
 	useSubmatch bool
+	// channels, when set, lists the only channel IDs where the reaction fires
+	channels []string
+	limit    config.ReactionLimit
+	class    reactionClass
+	// priority orders the reactions, highest first. Equal priorities keep
+	// their definition order.
+	priority int
+	re       *regexp.Regexp
 }
 
-// patternReactions contains all pattern-based reactions
-var patternReactions = []patternReaction{
-	// Greetings
-	{
-		pattern: `\bsalut|allo\b`,
-		handler: func(b *Bot, post *model.Post, replyToId string, _ [][]string) bool {
-			choices := []string{"aaaaaaayyeee", "sup", "yo"}
-			b.createReply(post.ChannelId, randomChoice(choices), replyToId, post.UserId)
-			return true
-		},
-	},
+// builtinReactions are the reactions only Go can express. They are checked
+// before the reactions file's reactions of equal priority, so a file
+// reaction needs a priority above 0 to answer before xd or charging up.
+var builtinReactions = []patternReaction{
+	karmaReaction,
 	// XD reaction
 	{
+		name:        "xd",
 		pattern:     `(xd+)`,
 		useSubmatch: true,
-		handler: func(b *Bot, post *model.Post, replyToId string, matched [][]string) bool {
+		limit:       config.ReactionLimit{Cooldown: new(10 * time.Minute)},
+		handler: func(b *Bot, post *model.Post, replyToId, _ string, matched [][]string) bool {
 			b.createPost(post.ChannelId, "haha "+matched[0][1], replyToId)
 			return true
 		},
 	},
-	// Anime/weeb reaction
-	{
-		pattern: `\banime|animuh|weeb|weaboo\b`,
-		handler: func(b *Bot, post *model.Post, replyToId string, _ [][]string) bool {
-			b.createPost(post.ChannelId, "### Disgusting weebs rolf :huel:", replyToId)
-			return true
-		},
-	},
-	// Vidya reaction
-	{
-		pattern: `\bvidya|bonshommes\b`,
-		handler: func(b *Bot, post *model.Post, replyToId string, _ [][]string) bool {
-			b.createPost(post.ChannelId, "rolf vous avez quel age?", replyToId)
-			return true
-		},
-	},
-	// Winter cycling
-	{
-		pattern: `\bvelo.*hiver\b`,
-		handler: func(b *Bot, post *model.Post, replyToId string, _ [][]string) bool {
-			b.createPost(post.ChannelId, "wow cest fukin dangereux faut vraiment etre retarded pour cycler en hiver (dans une tempete de verglas) :huel:", replyToId)
-			return true
-		},
-	},
-	// Goodbye
-	{
-		pattern: `\b:disappear:|peace|alp|bye|:wave:|see ya|au revoir|ciao|chow|a tantot\b`,
-		handler: func(b *Bot, post *model.Post, replyToId string, _ [][]string) bool {
-			b.createPost(post.ChannelId, "hey salut la, a prochaine, on se revoit, stait bin lfun", replyToId)
-			return true
-		},
-	},
-	// Good morning
-	{
-		pattern: `\bbon matin|morning|mornin\b`,
-		handler: func(b *Bot, post *model.Post, replyToId string, _ [][]string) bool {
-			choices := []string{"zzzz kill me now", "omgggggg"}
-			b.createPost(post.ChannelId, randomChoice(choices), replyToId)
-			return true
-		},
-	},
-	// Mirin
-	{
-		pattern: `\bmirin\b`,
-		handler: func(b *Bot, post *model.Post, replyToId string, _ [][]string) bool {
-			b.createReply(post.ChannelId, "fucking mirin", replyToId, post.UserId)
-			return true
-		},
-	},
-	// Wink emoji
-	{
-		pattern: `;-?\)(\s|$)|:wink:`,
-		handler: func(b *Bot, post *model.Post, _ string, _ [][]string) bool {
-			b.createReaction("wink", post.Id)
-			return true
-		},
-	},
-	// Tongue emoji
-	{
-		pattern: `:-?P(\s|$)|:stuck_out_tongue:`,
-		handler: func(b *Bot, post *model.Post, _ string, _ [][]string) bool {
-			b.createReaction("stuck_out_tongue", post.Id)
-			return true
-		},
-	},
-	// Fuck emoji
-	{
-		pattern: `:fuck:`,
-		handler: func(b *Bot, post *model.Post, _ string, _ [][]string) bool {
-			b.createReaction("fuck", post.Id)
-			return true
-		},
-	},
-	// Caret (^)
-	{
-		pattern: `(\s|^)\^(\s|$)`,
-		handler: func(b *Bot, post *model.Post, replyToId string, _ [][]string) bool {
-			b.createPost(post.ChannelId, "^", replyToId)
-			b.createReaction("point_up_2", post.Id)
-			return true
-		},
-	},
-	// "this"
-	{
-		pattern: `^this$`,
-		handler: func(b *Bot, post *model.Post, replyToId string, _ [][]string) bool {
-			b.createPost(post.ChannelId, "this", replyToId)
-			b.createReaction("point_up_2", post.Id)
-			return true
-		},
-	},
-	// Reddit
-	{
-		pattern: `\breddit\b`,
-		handler: func(b *Bot, post *model.Post, replyToId string, _ [][]string) bool {
-			b.createReply(post.ChannelId, "\\>reddit", replyToId, post.UserId)
-			return true
-		},
-	},
-	// Tumblr
-	{
-		pattern: `\btumblr\b`,
-		handler: func(b *Bot, post *model.Post, replyToId string, _ [][]string) bool {
-			b.createReply(post.ChannelId, "\\>tumblr", replyToId, post.UserId)
-			return true
-		},
-	},
-	// TGIF
-	{
-		pattern: `\btgif\b`,
-		handler: func(b *Bot, post *model.Post, replyToId string, _ [][]string) bool {
-			b.createReply(post.ChannelId, "tgiff*", replyToId, post.UserId)
-			return true
-		},
-	},
 	// Charging up
 	{
+		name:        "charging_up",
 		pattern:     `(a{5,}h{2,}!*)|:charging_up:`,
 		useSubmatch: true,
-		handler: func(b *Bot, post *model.Post, replyToId string, matched [][]string) bool {
+		handler: func(b *Bot, post *model.Post, replyToId, _ string, matched [][]string) bool {
 			match := matched[0][0]
 			length := len(match)
 			if match == ":charging_up:" {
-				length = rand.Intn(50) + 1
+				length = b.random.Intn(50) + 1
 			}
 			// x1 at 8 characters
 			// +1 multiplier every time you add 15 characters
@@ -171,25 +77,68 @@ var patternReactions = []patternReaction{
 	},
 }
 
-// handlePatternReactions checks all pattern reactions against the message
-func (b *Bot) handlePatternReactions(post *model.Post, replyToId string) bool {
-	for _, reaction := range patternReactions {
-		pattern := globalRegexOptions + reaction.pattern
-		re := regexp.MustCompile(pattern)
+// compileReactions compiles the pattern of every reaction
+func compileReactions(reactions []patternReaction) ([]patternReaction, error) {
+	compiled := make([]patternReaction, len(reactions))
+	for i, reaction := range reactions {
+		re, err := regexp.Compile(globalRegexOptions + reaction.pattern)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid pattern for reaction "+reaction.name)
+		}
+		reaction.re = re
+		compiled[i] = reaction
+	}
+	return compiled, nil
+}
+
+// sortReactions orders reactions by priority, keeping the definition order
+// of equal priorities: built-in reactions first, then the reactions file
+func sortReactions(reactions []patternReaction) {
+	sort.SliceStable(reactions, func(i, j int) bool {
+		return reactions[i].priority > reactions[j].priority
+	})
+}
 
+// handlePatternReactions checks all pattern reactions, then the learned
+// triggers, against the normalized text of the message. Every matching emoji
+// reaction fires, along with the first matching text reaction. It reports
+// whether a text reaction answered.
+func (b *Bot) handlePatternReactions(post *model.Post, text, replyToId string) bool {
+	answered := false
+	for _, reaction := range *b.reactions.Load() {
+		if reaction.class == classText && answered {
+			continue
+		}
+		if len(reaction.channels) > 0 && !slices.Contains(reaction.channels, post.ChannelId) {
+			continue
+		}
+
+		var matched [][]string
 		if reaction.useSubmatch {
-			if matched := re.FindAllStringSubmatch(post.Message, -1); matched != nil {
-				if reaction.handler(b, post, replyToId, matched) {
-					return true
-				}
+			if matched = reaction.re.FindAllStringSubmatch(text, -1); matched == nil {
+				continue
 			}
-		} else {
-			if matched, _ := regexp.MatchString(pattern, post.Message); matched {
-				if reaction.handler(b, post, replyToId, nil) {
-					return true
-				}
+		} else if !reaction.re.MatchString(text) {
+			continue
+		}
+
+		if b.allowReaction(reaction, post.ChannelId) && reaction.handler(b, post, replyToId, text, matched) {
+			reactionsTotal.Inc(reaction.name)
+			if reaction.class == classText {
+				answered = true
 			}
 		}
 	}
-	return false
+
+	// Then the triggers taught in chat
+	if !answered {
+		answered = b.handleLearnedTriggers(post, text, replyToId)
+	}
+	return answered
+}
+
+// allowReaction checks the reaction's limits in the channel. Reactions that
+// are held back let the next matching one answer instead.
+func (b *Bot) allowReaction(reaction patternReaction, channelId string) bool {
+	return b.limiter.allow(reaction.name, channelId, b.reactionLimit(reaction.name, channelId, reaction.limit))
 }
@@ -0,0 +1,209 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/opendwellers/jujubot/pkg/config"
+	"github.com/opendwellers/jujubot/pkg/normalize"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// defaultReactionsFile holds the reactions used when no reactions file exists
+//
+//go:embed reactions.yaml
+var defaultReactionsFile []byte
+
+// Response types of a reaction defined in the reactions file
+const (
+	reactionTypePost     = "post"
+	reactionTypeReply    = "reply"
+	reactionTypeReaction = "reaction"
+)
+
+// reactionSpec is a reaction as written in the reactions file
+type reactionSpec struct {
+	Name    string `mapstructure:"name"`
+	Pattern string `mapstructure:"pattern"`
+	// Type is post, reply (post mentioning the author) or reaction (emoji)
+	Type string `mapstructure:"type"`
+	// Choices are the possible messages, or emoji names for a reaction. One
+	// is picked at random and may refer to capture groups as $1 or ${name}.
+	Choices []string `mapstructure:"choices"`
+	// Emoji are added to the message in addition to the response
+	Emoji []string `mapstructure:"emoji"`
+	// Priority orders the reactions, highest first
+	Priority int `mapstructure:"priority"`
+	// Channels, when set, lists the only channel IDs where the reaction fires
+	Channels []string `mapstructure:"channels"`
+	// Cooldown, Probability and DailyCap limit how often the reaction fires
+	config.ReactionLimit `mapstructure:",squash"`
+}
+
+// loadReactions reads the reactions file, falling back to the embedded
+// defaults when it doesn't exist, and returns them after the built-in ones
+func loadReactions(path string) ([]patternReaction, error) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+
+	var err error
+	if _, statErr := os.Stat(path); path != "" && statErr == nil {
+		v.SetConfigFile(path)
+		err = v.ReadInConfig()
+	} else {
+		zap.S().Info("No reactions file found, using the default reactions")
+		err = v.ReadConfig(bytes.NewReader(defaultReactionsFile))
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read reactions")
+	}
+	return parseReactions(v)
+}
+
+// parseReactions builds the reactions defined in v
+func parseReactions(v *viper.Viper) ([]patternReaction, error) {
+	var specs []reactionSpec
+	if err := v.UnmarshalKey("reactions", &specs); err != nil {
+		return nil, errors.Wrap(err, "failed to decode reactions")
+	}
+
+	reactions, err := compileReactions(builtinReactions)
+	if err != nil {
+		return nil, err
+	}
+	for i, spec := range specs {
+		reaction, err := spec.reaction()
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid reaction #%d", i+1)
+		}
+		reactions = append(reactions, reaction)
+	}
+	sortReactions(reactions)
+	return reactions, nil
+}
+
+// reaction validates the spec and turns it into a pattern reaction
+func (s reactionSpec) reaction() (patternReaction, error) {
+	if s.Name == "" {
+		return patternReaction{}, errors.New("missing name")
+	}
+	// Messages are matched without accents, so the pattern is too
+	re, err := regexp.Compile(globalRegexOptions + normalize.StripDiacritics(s.Pattern))
+	if err != nil || s.Pattern == "" {
+		return patternReaction{}, errors.Errorf("invalid pattern for reaction %s: %v", s.Name, err)
+	}
+	switch s.Type {
+	case reactionTypePost, reactionTypeReply, reactionTypeReaction:
+	default:
+		return patternReaction{}, errors.Errorf("unknown type %q for reaction %s", s.Type, s.Name)
+	}
+	if len(s.Choices) == 0 {
+		return patternReaction{}, errors.New("no choices for reaction " + s.Name)
+	}
+
+	// Emoji stack, but a message gets a single text answer
+	class := classText
+	if s.Type == reactionTypeReaction {
+		class = classEmoji
+	}
+
+	return patternReaction{
+		name:     s.Name,
+		pattern:  s.Pattern,
+		channels: s.Channels,
+		limit:    s.ReactionLimit,
+		class:    class,
+		priority: s.Priority,
+		re:       re,
+		handler: func(b *Bot, post *model.Post, replyToId, text string, _ [][]string) bool {
+			choice := b.randomChoice(s.Choices)
+			response := string(re.ExpandString(nil, choice, text, re.FindStringSubmatchIndex(text)))
+
+			switch s.Type {
+			case reactionTypePost:
+				b.createPost(post.ChannelId, response, replyToId)
+			case reactionTypeReply:
+				b.createReply(post.ChannelId, response, replyToId, post.UserId)
+			case reactionTypeReaction:
+				b.createReaction(response, post.Id)
+			}
+			for _, emoji := range s.Emoji {
+				b.createReaction(emoji, post.Id)
+			}
+			return true
+		},
+	}, nil
+}
+
+// watchReactions reloads the reactions whenever the reactions file changes,
+// until ctx is cancelled. The directory is watched rather than the file so
+// editors that replace the file on save are picked up too.
+func (b *Bot) watchReactions(ctx context.Context) {
+	path := b.config.ReactionsFile
+	if _, err := os.Stat(path); path == "" || err != nil {
+		return
+	}
+	path = filepath.Clean(path)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		zap.S().Error("Failed to watch the reactions file", zap.Error(err))
+		return
+	}
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		zap.S().Error("Failed to watch the reactions file", zap.Error(err))
+		_ = watcher.Close()
+		return
+	}
+
+	// Tracked as in flight so shutdown waits for the watcher to close
+	b.inflight.Add(1)
+	go func() {
+		defer b.inflight.Done()
+		defer watcher.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) == path && event.Has(fsnotify.Write|fsnotify.Create) {
+					b.reloadReactions(path)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				zap.S().Error("Reactions file watcher failed", zap.Error(err))
+			}
+		}
+	}()
+}
+
+// reloadReactions replaces the reactions with those in the file at path.
+// A file that fails to load keeps the previous reactions in place.
+func (b *Bot) reloadReactions(path string) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		zap.S().Error("Failed to reload reactions, keeping the previous ones", zap.Error(err))
+		return
+	}
+	reactions, err := parseReactions(v)
+	if err != nil {
+		zap.S().Error("Failed to reload reactions, keeping the previous ones", zap.Error(err))
+		return
+	}
+	b.reactions.Store(&reactions)
+	zap.S().Infof("Reloaded %d reactions from %s", len(reactions), path)
+}
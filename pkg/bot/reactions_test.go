@@ -0,0 +1,247 @@
+package bot
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/opendwellers/jujubot/pkg/config"
+	"github.com/opendwellers/jujubot/pkg/normalize"
+	"github.com/opendwellers/jujubot/pkg/store"
+)
+
+// chatCorpus is a sample of everyday messages, most of which match nothing
+var chatCorpus = []string{
+	"salut tout le monde",
+	"quelqu'un a vu le match hier?",
+	"ouais c'etait fou, le gardien a tout arrete",
+	"xd",
+	"je pense que je vais commander de la pizza ce soir",
+	"vous faites quoi en fin de semaine?",
+	"rien de special, probablement du ménage",
+	"https://www.youtube.com/watch?v=dQw4w9WgXcQ regarde ca",
+	"> c'etait fou\nvraiment pas",
+	"j'ai enfin fini le rapport :tada:",
+	"bon matin",
+	"quelqu'un veut aller manger au resto vietnamien?",
+	"ok ok ;)",
+	"j'ai un bug bizarre avec `go test`, ca compile pas",
+	"```\npanic: runtime error\n```",
+	"il fait tellement froid dehors",
+	"je suis allé au travail en vélo en hiver",
+	"this",
+	"aaaaaaaaaaahhhhhh",
+	"huel++",
+	"pizza--",
+	"lol",
+	"tgif",
+	"la reunion est deplacee a 15h",
+	"bye, a demain",
+	"qui joue a dota ce soir?",
+	"reddit est down encore",
+	"merci pour l'aide tantot",
+	"ca marche, je m'en occupe",
+	"je reviens dans 5 minutes",
+}
+
+func TestBadReactionPatternFailsStartup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reactions.yaml")
+	content := "reactions:\n  - name: broken\n    pattern: '(unclosed'\n    type: post\n    choices: [nope]\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewFakeClient(&model.User{Id: "bot", Username: "jujubot"})
+	_, err := NewWithClient(config.Config{ReactionsFile: path}, client, store.NewMemory())
+	if err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("got error %v, want one about reaction broken", err)
+	}
+}
+
+func TestBadBuiltinReactionFailsStartup(t *testing.T) {
+	saved := builtinReactions
+	t.Cleanup(func() { builtinReactions = saved })
+	builtinReactions = append([]patternReaction{{name: "broken", pattern: `[z-a]`}}, saved...)
+
+	client := NewFakeClient(&model.User{Id: "bot", Username: "jujubot"})
+	if _, err := NewWithClient(config.Config{}, client, store.NewMemory()); err == nil {
+		t.Error("got no error for an invalid built-in pattern")
+	}
+}
+
+// benchmarkPosts returns the corpus as posts, with their normalized text
+func benchmarkPosts() ([]*model.Post, []string) {
+	posts := make([]*model.Post, len(chatCorpus))
+	texts := make([]string, len(chatCorpus))
+	for i, message := range chatCorpus {
+		posts[i] = &model.Post{Id: model.NewId(), ChannelId: testChannelId, UserId: "alice", Message: message}
+		texts[i] = normalize.Message(message)
+	}
+	return posts, texts
+}
+
+// BenchmarkHandlePatternReactions checks the corpus against the compiled
+// reactions
+func BenchmarkHandlePatternReactions(b *testing.B) {
+	bot, client := newTestBot(b, config.Config{})
+	posts, texts := benchmarkPosts()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		n := i % len(posts)
+		bot.handlePatternReactions(posts[n], texts[n], "")
+		if i%1000 == 0 {
+			client.Reset()
+		}
+	}
+}
+
+// BenchmarkRecompiledPatternReactions matches the corpus the way reactions
+// used to be matched, compiling every pattern for every message, as the
+// baseline of BenchmarkHandlePatternReactions
+func BenchmarkRecompiledPatternReactions(b *testing.B) {
+	bot, _ := newTestBot(b, config.Config{})
+	reactions := *bot.reactions.Load()
+	_, texts := benchmarkPosts()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		text := texts[i%len(texts)]
+		for _, reaction := range reactions {
+			regexp.MustCompile(globalRegexOptions + reaction.pattern).MatchString(text)
+		}
+	}
+}
+
+// BenchmarkHandleMessage runs whole messages of the corpus through
+// normalization, commands and reactions
+func BenchmarkHandleMessage(b *testing.B) {
+	bot, client := newTestBot(b, config.Config{})
+	posts, _ := benchmarkPosts()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bot.handleMessage(posts[i%len(posts)])
+		if i%1000 == 0 {
+			client.Reset()
+		}
+	}
+}
+
+func writeReactions(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReloadReactions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reactions.yaml")
+	writeReactions(t, path, "reactions:\n  - name: ping\n    pattern: '\\bping\\b'\n    type: post\n    choices: [pong]\n")
+	b, client := newTestBot(t, config.Config{ReactionsFile: path})
+
+	say(b, "alice", "ping")
+	if got := postedMessages(client); !slices.Equal(got, []string{"pong"}) {
+		t.Fatalf("got %q before reloading, want pong", got)
+	}
+
+	// Choices can use the pattern's capture groups
+	writeReactions(t, path, "reactions:\n  - name: greet\n    pattern: '\\bsalut (?P<who>\\w+)'\n    type: reply\n    choices: ['${who} te dit salut, $1']\n")
+	b.reloadReactions(path)
+	client.Reset()
+	say(b, "alice", "ping")
+	say(b, "alice", "salut bob")
+	if got, want := postedMessages(client), []string{"@alice: bob te dit salut, bob"}; !slices.Equal(got, want) {
+		t.Errorf("got %q after reloading, want %q", got, want)
+	}
+
+	for _, content := range []string{
+		"reactions:\n  - name: broken\n    pattern: '(unclosed'\n    type: post\n    choices: [nope]\n",
+		"reactions: [this is: not yaml\n",
+	} {
+		writeReactions(t, path, content)
+		b.reloadReactions(path)
+		client.Reset()
+		say(b, "alice", "salut bob")
+		if got, want := postedMessages(client), []string{"@alice: bob te dit salut, bob"}; !slices.Equal(got, want) {
+			t.Errorf("got %q after an invalid reload, want the previous reactions %q", got, want)
+		}
+	}
+
+	// Patterns are matched without accents, like the messages
+	writeReactions(t, path, "reactions:\n  - name: dessert\n    pattern: '\\bcrème brûlée\\b'\n    type: post\n    choices: [miam]\n")
+	b.reloadReactions(path)
+	client.Reset()
+	say(b, "alice", "une crème brûlée svp")
+	if got := postedMessages(client); !slices.Equal(got, []string{"miam"}) {
+		t.Errorf("got %q for an accented pattern, want miam", got)
+	}
+}
+
+func TestWatchReactions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reactions.yaml")
+	writeReactions(t, path, "reactions:\n  - name: ping\n    pattern: '\\bping\\b'\n    type: post\n    choices: [pong]\n")
+	b, client := newTestBot(t, config.Config{ReactionsFile: path})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b.watchReactions(ctx)
+
+	writeReactions(t, path, "reactions:\n  - name: ping\n    pattern: '\\bping\\b'\n    type: post\n    choices: [pang]\n")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		client.Reset()
+		say(b, "alice", "ping")
+		if got := postedMessages(client); slices.Equal(got, []string{"pang"}) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("reactions weren't reloaded after the file changed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	stopped := make(chan struct{})
+	go func() {
+		b.inflight.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("the watcher didn't stop after the context was cancelled")
+	}
+}
+
+func TestBuiltinReactionsWinTies(t *testing.T) {
+	tests := []struct {
+		name     string
+		priority int
+		want     []string
+	}{
+		{"equal priority", 0, []string{"haha xd"}},
+		{"higher priority", 1, []string{"@alice: yo"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "reactions.yaml")
+			writeReactions(t, path, "reactions:\n  - name: greeting\n    pattern: '\\bsalut\\b'\n    type: reply\n    choices: [yo]\n    priority: "+strconv.Itoa(tt.priority)+"\n")
+			b, client := newTestBot(t, config.Config{ReactionsFile: path})
+
+			say(b, "alice", "salut xd")
+			if got := postedMessages(client); !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package bot
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/pkg/errors"
+)
+
+// Command describes a named command, i.e. a message starting with @botname
+type Command struct {
+	// Name is the main keyword of the command, e.g. "weather"
+	Name string
+	// Aliases are other keywords triggering the command. Keywords may contain spaces.
+	Aliases []string
+	// Description is a one-line summary of what the command does
+	Description string
+	// Usage shows the arguments the command takes, e.g. "weather [now] [location]"
+	Usage string
+	// Examples are full invocations, without the @botname prefix
+	Examples []string
+	// Args is matched against everything after the keyword. Its submatches
+	// are passed to the handler. An empty pattern accepts no arguments.
+	Args string
+	// Handler runs the command
+	Handler func(b *Bot, req *CommandRequest)
+	// Only420 restricts the command to April 20th
+	Only420 bool
+	// AdminOnly restricts the command to the configured admins
+	AdminOnly bool
+
+	args *regexp.Regexp
+}
+
+// Keywords returns the name followed by the aliases
+func (c *Command) Keywords() []string {
+	return append([]string{c.Name}, c.Aliases...)
+}
+
+// CommandRequest is a single invocation of a command
+type CommandRequest struct {
+	Post      *model.Post
+	ReplyToId string
+	// Keyword is the keyword the command was invoked with
+	Keyword string
+	// Args holds the submatches of the command's argument pattern.
+	// Args[0] is the whole argument string.
+	Args []string
+}
+
+// Registry holds the named commands, indexed by keyword
+type Registry struct {
+	commands []*Command
+	keywords []registeredKeyword // longest first, so "weather now" beats "weather"
+}
+
+type registeredKeyword struct {
+	keyword string
+	command *Command
+}
+
+// NewRegistry creates an empty command registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a command and compiles its argument pattern. Keywords must
+// be unique across commands.
+func (r *Registry) Register(cmd *Command) error {
+	if cmd.Name == "" || cmd.Handler == nil {
+		return errors.New("command needs a name and a handler")
+	}
+
+	args, err := regexp.Compile(globalRegexOptions + `^(?:` + cmd.Args + `)$`)
+	if err != nil {
+		return errors.Wrap(err, "invalid argument pattern for command "+cmd.Name)
+	}
+	cmd.args = args
+
+	for _, keyword := range cmd.Keywords() {
+		if r.lookupKeyword(keyword) != nil {
+			return errors.New("duplicate command keyword: " + keyword)
+		}
+	}
+
+	r.commands = append(r.commands, cmd)
+	for _, keyword := range cmd.Keywords() {
+		r.keywords = append(r.keywords, registeredKeyword{keyword: keyword, command: cmd})
+	}
+	sort.SliceStable(r.keywords, func(i, j int) bool {
+		return len(r.keywords[i].keyword) > len(r.keywords[j].keyword)
+	})
+	return nil
+}
+
+// Commands returns every registered command in registration order
+func (r *Registry) Commands() []*Command {
+	return slices.Clone(r.commands)
+}
+
+// Lookup returns the command with the given name or alias, or nil
+func (r *Registry) Lookup(keyword string) *Command {
+	return r.lookupKeyword(strings.TrimSpace(keyword))
+}
+
+func (r *Registry) lookupKeyword(keyword string) *Command {
+	for _, k := range r.keywords {
+		if strings.EqualFold(k.keyword, keyword) {
+			return k.command
+		}
+	}
+	return nil
+}
+
+// match finds the command whose keyword starts text and returns the keyword
+// and the remaining argument string
+func (r *Registry) match(text string) (*Command, string, string) {
+	for _, k := range r.keywords {
+		n := len(k.keyword)
+		if len(text) < n || !strings.EqualFold(text[:n], k.keyword) {
+			continue
+		}
+		if len(text) > n && text[n] != ' ' {
+			continue
+		}
+		return k.command, k.keyword, strings.TrimLeft(text[n:], " ")
+	}
+	return nil, "", ""
+}
+
+// parseArgs matches an argument string against the command's pattern
+func (c *Command) parseArgs(args string) []string {
+	return c.args.FindStringSubmatch(args)
+}
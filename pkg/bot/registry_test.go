@@ -0,0 +1,75 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/opendwellers/jujubot/pkg/config"
+)
+
+func TestRegistryRegister(t *testing.T) {
+	noop := func(*Bot, *CommandRequest) {}
+	tests := []struct {
+		name    string
+		cmd     *Command
+		wantErr bool
+	}{
+		{"valid", &Command{Name: "ping", Args: `(\d+)?`, Handler: noop}, false},
+		{"no arguments", &Command{Name: "ping", Handler: noop}, false},
+		{"invalid arguments pattern", &Command{Name: "ping", Args: `(\d+`, Handler: noop}, true},
+		{"missing name", &Command{Handler: noop}, true},
+		{"missing handler", &Command{Name: "ping"}, true},
+		{"duplicate keyword", &Command{Name: "pong", Aliases: []string{"Help"}, Handler: noop}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry()
+			if err := r.Register(&Command{Name: "help", Handler: noop}); err != nil {
+				t.Fatal(err)
+			}
+			if err := r.Register(tt.cmd); (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDefaultCommandsCompile(t *testing.T) {
+	r := NewRegistry()
+	for _, cmd := range defaultCommands() {
+		if err := r.Register(cmd); err != nil {
+			t.Errorf("command %s: %v", cmd.Name, err)
+		}
+	}
+}
+
+// BenchmarkHandleNamedCommands runs the commands of the chat corpus that
+// don't call external services
+func BenchmarkHandleNamedCommands(b *testing.B) {
+	bot, client := newTestBot(b, config.Config{})
+	commands := []string{
+		"@jujubot I love you",
+		"@jujubot merci",
+		"@jujubot est-ce que huel va venir?",
+		"@jujubot roll",
+		"@jujubot roll 2d6+3",
+		"@jujubot karma huel",
+		"@jujubot charge level",
+		"@jujubot help roll",
+		"@jujubot triggers",
+		"@jujubot dance",
+	}
+	posts := make([]*model.Post, len(commands))
+	for i, message := range commands {
+		posts[i] = &model.Post{Id: model.NewId(), ChannelId: testChannelId, UserId: "alice", Message: message}
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bot.handleNamedCommands(posts[i%len(posts)], "")
+		if i%1000 == 0 {
+			client.Reset()
+		}
+	}
+}
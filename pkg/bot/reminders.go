@@ -0,0 +1,271 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/opendwellers/jujubot/pkg/commands"
+	"github.com/opendwellers/jujubot/pkg/store"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	// remindersCollection stores the pending reminders, keyed by ID
+	remindersCollection = "reminders"
+	// remindersMetaCollection stores the last reminder ID under remindersLastIdKey
+	remindersMetaCollection = "reminders_meta"
+	remindersLastIdKey      = "last_id"
+
+	maxReminderDelay         = 366 * 24 * time.Hour
+	maxReminderLength        = 500
+	maxRemindersPerUser      = 20
+	reminderDeliveryInterval = 15 * time.Second
+)
+
+// errTooManyReminders is returned when a user has too many pending reminders
+var errTooManyReminders = errors.Errorf("you already have %d reminders", maxRemindersPerUser)
+
+// reminder is a message to send back to a user at a given time
+type reminder struct {
+	Id        int    `json:"id"`
+	UserId    string `json:"user_id"`
+	ChannelId string `json:"channel_id"`
+	// RootId is the thread the reminder is delivered in
+	RootId  string `json:"root_id"`
+	Message string `json:"message"`
+	// Direct delivers the reminder as a direct message
+	Direct    bool      `json:"direct"`
+	DueAt     time.Time `json:"due_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// handleRemindCommand schedules a reminder, in the thread of the command or
+// as a direct message
+func (b *Bot) handleRemindCommand(req *CommandRequest) {
+	post := req.Post
+	direct := req.Args[2] != ""
+
+	now := b.userNow(post.UserId)
+	due, message, err := commands.ParseReminder(req.Args[3], now)
+	switch {
+	case err != nil:
+		b.createReply(post.ChannelId, "Hein? "+err.Error(), req.ReplyToId, post.UserId)
+		return
+	case due.Sub(now) > maxReminderDelay:
+		b.createReply(post.ChannelId, "That's too far away, I'll have forgotten by then", req.ReplyToId, post.UserId)
+		return
+	case utf8.RuneCountInString(message) > maxReminderLength:
+		b.createReply(post.ChannelId, fmt.Sprintf("Keep it under %d characters", maxReminderLength), req.ReplyToId, post.UserId)
+		return
+	case massMention.MatchString(message):
+		b.createReply(post.ChannelId, "Nope: no "+massMention.FindString(message)+" in reminders", req.ReplyToId, post.UserId)
+		return
+	}
+
+	rootId := post.RootId
+	if rootId == "" {
+		rootId = post.Id
+	}
+	r := reminder{
+		UserId:    post.UserId,
+		ChannelId: post.ChannelId,
+		RootId:    rootId,
+		Message:   message,
+		Direct:    direct,
+		DueAt:     due,
+		CreatedAt: now,
+	}
+	err = b.store.Update(func(tx store.Tx) error {
+		if len(userReminders(tx, post.UserId)) >= maxRemindersPerUser {
+			return errTooManyReminders
+		}
+		if _, err := tx.Get(remindersMetaCollection, remindersLastIdKey, &r.Id); err != nil {
+			return err
+		}
+		r.Id++
+		if err := tx.Put(remindersMetaCollection, remindersLastIdKey, r.Id); err != nil {
+			return err
+		}
+		return tx.Put(remindersCollection, strconv.Itoa(r.Id), r)
+	})
+	switch {
+	case errors.Is(err, errTooManyReminders):
+		b.createReply(post.ChannelId, "Nope: "+err.Error(), req.ReplyToId, post.UserId)
+		return
+	case err != nil:
+		zap.S().Error("Failed to save reminder", zap.Error(err))
+		b.createReply(post.ChannelId, "Couldn't save your reminder :pepehands:", req.ReplyToId, post.UserId)
+		return
+	}
+
+	where := "here"
+	if direct {
+		where = "in private"
+	}
+	b.createReply(post.ChannelId, fmt.Sprintf("ok, I'll remind you %s on %s (#%d)", where, formatReminderTime(due), r.Id), req.ReplyToId, post.UserId)
+}
+
+// handleRemindersCommand lists or cancels the user's reminders
+func (b *Bot) handleRemindersCommand(req *CommandRequest) {
+	post := req.Post
+	if req.Args[2] != "" {
+		b.cancelReminder(req)
+		return
+	}
+
+	var reminders []reminder
+	_ = b.store.View(func(tx store.Tx) error {
+		reminders = userReminders(tx, post.UserId)
+		return nil
+	})
+	if len(reminders) == 0 {
+		b.createReply(post.ChannelId, "You have no reminders. Try `@"+b.user.Username+" remind me in 2h to stretch`.", req.ReplyToId, post.UserId)
+		return
+	}
+
+	location := b.userLocation(post.UserId)
+	var sb strings.Builder
+	sb.WriteString("#### Reminders of " + b.getUserMention(post.UserId) + "\n")
+	for _, r := range reminders {
+		message := r.Message
+		// Only show the reminders set in this channel to its members
+		if r.Direct || r.ChannelId != post.ChannelId {
+			message = "_private_"
+		}
+		fmt.Fprintf(&sb, "- #%d %s: %s\n", r.Id, formatReminderTime(r.DueAt.In(location)), message)
+	}
+	b.createPost(post.ChannelId, sb.String(), req.ReplyToId)
+}
+
+// cancelReminder removes a reminder. Only its owner and the admins can
+// remove it.
+func (b *Bot) cancelReminder(req *CommandRequest) {
+	post := req.Post
+	id, _ := strconv.Atoi(req.Args[2])
+
+	var r reminder
+	found, err := store.Get(b.store, remindersCollection, strconv.Itoa(id), &r)
+	switch {
+	case err != nil:
+		zap.S().Error("Failed to load reminder", zap.Error(err))
+		return
+	case !found:
+		b.createReply(post.ChannelId, fmt.Sprintf("I don't know any reminder #%d", id), req.ReplyToId, post.UserId)
+		return
+	case r.UserId != post.UserId && !b.isAdmin(post.UserId):
+		b.createReply(post.ChannelId, "lol no, that's not your reminder", req.ReplyToId, post.UserId)
+		return
+	}
+
+	// It may have been delivered in the meantime
+	err = b.store.Update(func(tx store.Tx) error {
+		if found, err = tx.Get(remindersCollection, strconv.Itoa(id), &r); err != nil || !found {
+			return err
+		}
+		return tx.Delete(remindersCollection, strconv.Itoa(id))
+	})
+	switch {
+	case err != nil:
+		zap.S().Error("Failed to cancel reminder", zap.Error(err))
+	case !found:
+		b.createReply(post.ChannelId, fmt.Sprintf("too late, #%d is gone", id), req.ReplyToId, post.UserId)
+	default:
+		b.createReply(post.ChannelId, fmt.Sprintf("cancelled #%d", id), req.ReplyToId, post.UserId)
+	}
+}
+
+// userReminders returns the pending reminders of a user, soonest first
+func userReminders(tx store.Tx, userId string) []reminder {
+	var reminders []reminder
+	for _, key := range tx.Keys(remindersCollection) {
+		var r reminder
+		if _, err := tx.Get(remindersCollection, key, &r); err != nil || r.UserId != userId {
+			continue
+		}
+		reminders = append(reminders, r)
+	}
+	sort.Slice(reminders, func(i, j int) bool {
+		return reminders[i].DueAt.Before(reminders[j].DueAt)
+	})
+	return reminders
+}
+
+// startReminders delivers due reminders in the background until ctx is
+// cancelled, starting with those due while the bot was down
+func (b *Bot) startReminders(ctx context.Context) {
+	// Tracked as in flight so shutdown doesn't close the store under it
+	b.inflight.Add(1)
+	go func() {
+		defer b.inflight.Done()
+		ticker := time.NewTicker(reminderDeliveryInterval)
+		defer ticker.Stop()
+
+		b.deliverReminders(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				b.deliverReminders(ctx)
+			}
+		}
+	}()
+}
+
+// deliverReminders sends every reminder that is due. Reminders are removed
+// before being sent so a crash doesn't send them twice.
+func (b *Bot) deliverReminders(ctx context.Context) {
+	now := b.clock.Now()
+	var due []reminder
+	err := b.store.Update(func(tx store.Tx) error {
+		for _, key := range tx.Keys(remindersCollection) {
+			var r reminder
+			if _, err := tx.Get(remindersCollection, key, &r); err != nil {
+				return err
+			}
+			if r.DueAt.After(now) {
+				continue
+			}
+			if err := tx.Delete(remindersCollection, key); err != nil {
+				return err
+			}
+			due = append(due, r)
+		}
+		return nil
+	})
+	if err != nil {
+		zap.S().Error("Failed to read due reminders", zap.Error(err))
+		return
+	}
+
+	for _, r := range due {
+		// Reminders saved before mass mentions were refused still have them
+		message := ":alarm_clock: " + massMention.ReplaceAllString(r.Message, "`$0`")
+		if late := now.Sub(r.DueAt); late > time.Hour {
+			message += " _(sorry, " + strings.TrimSuffix(late.Round(time.Minute).String(), "0s") + " late)_"
+		}
+
+		if !r.Direct {
+			b.createReply(r.ChannelId, message, r.RootId, r.UserId)
+			continue
+		}
+		channel, err := b.client.CreateDirectChannel(ctx, b.user.Id, r.UserId)
+		if err != nil {
+			zap.S().Error("Failed to open direct channel, reminding in the thread instead", zap.Error(err))
+			b.createReply(r.ChannelId, message, r.RootId, r.UserId)
+			continue
+		}
+		b.createPost(channel.Id, message, "")
+	}
+}
+
+// formatReminderTime shows when a reminder is due, in the time zone of t
+func formatReminderTime(t time.Time) string {
+	return t.Format("Mon Jan 2 15:04 MST")
+}
@@ -0,0 +1,141 @@
+package bot
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/opendwellers/jujubot/pkg/config"
+	"github.com/opendwellers/jujubot/pkg/store"
+)
+
+// sayIn handles a message from userId posted in a channel
+func sayIn(b *Bot, channelId, userId, message string) {
+	b.handleMessage(&model.Post{Id: model.NewId(), ChannelId: channelId, UserId: userId, Message: message})
+}
+
+func TestRemindersList(t *testing.T) {
+	b, client := newTestBot(t, config.Config{})
+	say(b, "alice", "@jujubot remind me in 2h to stretch")
+	sayIn(b, "secret", "alice", "@jujubot remind me in 1h to call the lawyer")
+	say(b, "alice", "@jujubot remind me dm in 3h to buy a gift")
+	say(b, "bob", "@jujubot remind me in 1h to drink water")
+	client.Reset()
+
+	say(b, "alice", "@jujubot reminders")
+	sayIn(b, "secret", "alice", "@jujubot reminders")
+	say(b, "bob", "@jujubot rappels")
+
+	want := []string{
+		"#### Reminders of @alice\n" +
+			"- #2 Thu Jan 15 13:00 UTC: _private_\n" +
+			"- #1 Thu Jan 15 14:00 UTC: stretch\n" +
+			"- #3 Thu Jan 15 15:00 UTC: _private_\n",
+		"#### Reminders of @alice\n" +
+			"- #2 Thu Jan 15 13:00 UTC: call the lawyer\n" +
+			"- #1 Thu Jan 15 14:00 UTC: _private_\n" +
+			"- #3 Thu Jan 15 15:00 UTC: _private_\n",
+		"#### Reminders of @bob\n" +
+			"- #4 Thu Jan 15 13:00 UTC: drink water\n",
+	}
+	if got := postedMessages(client); !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRemindersCancel(t *testing.T) {
+	tests := []struct {
+		name     string
+		userId   string
+		message  string
+		want     string
+		wantLeft int
+	}{
+		{"owner", "alice", "@jujubot reminders cancel #1", "@alice: cancelled #1", 0},
+		{"owner in French", "alice", "@jujubot rappels annule 1", "@alice: cancelled #1", 0},
+		{"someone else", "bob", "@jujubot reminders cancel 1", "@bob: lol no, that's not your reminder", 1},
+		{"admin", "admin", "@jujubot reminders cancel 1", "@admin: cancelled #1", 0},
+		{"unknown reminder", "alice", "@jujubot reminders cancel 2", "@alice: I don't know any reminder #2", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, client := newTestBot(t, config.Config{Admins: []string{"@admin"}})
+			client.AddUser(&model.User{Id: "admin", Username: "admin"})
+			say(b, "alice", "@jujubot remind me in 2h to stretch")
+			client.Reset()
+
+			say(b, tt.userId, tt.message)
+			if got := postedMessages(client); !slices.Equal(got, []string{tt.want}) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			b.deliverReminders(t.Context())
+			b.clock.(*FakeClock).Advance(3 * time.Hour)
+			client.Reset()
+			b.deliverReminders(t.Context())
+			if got := len(client.Posts()); got != tt.wantLeft {
+				t.Errorf("delivered %d reminders, want %d", got, tt.wantLeft)
+			}
+		})
+	}
+}
+
+func TestReminderDelivery(t *testing.T) {
+	b, client := newTestBot(t, config.Config{})
+	post := say(b, "alice", "@jujubot remind me in 2h to stretch")
+	say(b, "alice", "@jujubot rappelle-moi en privé dans 3h de boire")
+	client.Reset()
+	clock := b.clock.(*FakeClock)
+
+	clock.Advance(2*time.Hour - time.Second)
+	b.deliverReminders(t.Context())
+	if got := client.Posts(); len(got) != 0 {
+		t.Fatalf("delivered %d reminders early", len(got))
+	}
+
+	// The second one is late, e.g. the bot was down
+	clock.Advance(5 * time.Hour)
+	b.deliverReminders(t.Context())
+	b.deliverReminders(t.Context())
+	posts := client.Posts()
+	if len(posts) != 2 {
+		t.Fatalf("delivered %d reminders, want 2 once", len(posts))
+	}
+	if got := posts[0]; got.Message != "@alice: :alarm_clock: stretch _(sorry, 5h0m late)_" || got.RootId != post.Id || got.ChannelId != testChannelId {
+		t.Errorf("got %q in %s under %s, want it in the thread of the command", got.Message, got.ChannelId, got.RootId)
+	}
+	if got := posts[1]; got.Message != ":alarm_clock: boire _(sorry, 4h0m late)_" || got.ChannelId == testChannelId {
+		t.Errorf("got %q in %s, want it in a direct channel", got.Message, got.ChannelId)
+	}
+}
+
+func TestReminderMassMention(t *testing.T) {
+	b, client := newTestBot(t, config.Config{})
+	say(b, "alice", "@jujubot remind me in 2h to ping @here")
+	say(b, "alice", "@jujubot remind me in 2h to ping @ALL")
+	say(b, "alice", "@jujubot remind me in 2h to ping @heres_johnny")
+
+	want := []string{"@alice: Nope: no @here in reminders", "@alice: Nope: no @ALL in reminders"}
+	if got := postedMessages(client); len(got) != 3 || !slices.Equal(got[:2], want) {
+		t.Errorf("got %q, want %q then a confirmation", got, want)
+	}
+	var count int
+	_ = b.store.View(func(tx store.Tx) error {
+		count = len(tx.Keys(remindersCollection))
+		return nil
+	})
+	if count != 1 {
+		t.Errorf("saved %d reminders, want only the one without a mass mention", count)
+	}
+
+	// Reminders saved before they were refused are escaped on delivery
+	legacy := reminder{Id: 99, UserId: "alice", ChannelId: testChannelId, RootId: "root", Message: "wake up @channel", DueAt: testNow}
+	if err := store.Put(b.store, remindersCollection, "99", legacy); err != nil {
+		t.Fatal(err)
+	}
+	client.Reset()
+	b.deliverReminders(t.Context())
+	if got, want := postedMessages(client), []string{"@alice: :alarm_clock: wake up `@channel`"}; !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,96 @@
+package bot
+
+import (
+	"context"
+	"time"
+
+	"github.com/opendwellers/jujubot/pkg/commands"
+	"github.com/opendwellers/jujubot/pkg/config"
+	"github.com/opendwellers/jujubot/pkg/scheduler"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// scheduledMessages builds the message of each kind of scheduled job
+var scheduledMessages = map[string]func(b *Bot, job config.ScheduledJob) (string, error){
+	"wotd": func(b *Bot, job config.ScheduledJob) (string, error) {
+		return commands.GetWotdJapanese()
+	},
+	"weather": func(b *Bot, job config.ScheduledJob) (string, error) {
+		location := job.Location
+		if location == "" {
+			location = "Montreal"
+		}
+		return b.weatherClient.GetWeather(location)
+	},
+	"announce": func(b *Bot, job config.ScheduledJob) (string, error) {
+		return job.Message, nil
+	},
+}
+
+// loadSchedule registers the configured jobs, failing on invalid ones
+func (b *Bot) loadSchedule() error {
+	b.scheduler = scheduler.New(b.store, b.clock.Now)
+
+	for _, job := range b.config.Schedule {
+		name := job.Name
+		if name == "" {
+			name = job.Kind
+		}
+
+		build, ok := scheduledMessages[job.Kind]
+		if !ok {
+			return errors.Errorf("unknown kind %q of scheduled job %s", job.Kind, name)
+		}
+		if job.Channel == "" {
+			return errors.New("scheduled job " + name + " needs a channel")
+		}
+		if job.Kind == "announce" && job.Message == "" {
+			return errors.New("scheduled job " + name + " needs a message")
+		}
+		schedule, err := scheduler.Parse(job.Cron)
+		if err != nil {
+			return errors.Wrap(err, "invalid schedule of job "+name)
+		}
+		location := b.location
+		if job.Timezone != "" {
+			if location, err = time.LoadLocation(job.Timezone); err != nil {
+				return errors.Wrap(err, "invalid timezone of job "+name)
+			}
+		}
+
+		err = b.scheduler.Add(scheduler.Job{
+			Name:     name,
+			Schedule: schedule,
+			Location: location,
+			Run: func(ctx context.Context) error {
+				message, err := build(b, job)
+				if err != nil {
+					return err
+				}
+				b.createPost(job.Channel, message, "")
+				return nil
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startScheduler runs the scheduled jobs in the background until ctx is
+// cancelled
+func (b *Bot) startScheduler(ctx context.Context) {
+	if b.scheduler.Len() == 0 {
+		return
+	}
+	zap.S().Info("Starting scheduler with ", b.scheduler.Len(), " jobs")
+
+	// Tracked as in flight so shutdown doesn't close the store under it
+	b.inflight.Add(1)
+	go func() {
+		defer b.inflight.Done()
+		b.scheduler.Start(ctx)
+	}()
+}
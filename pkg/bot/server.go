@@ -0,0 +1,99 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/opendwellers/jujubot/pkg/health"
+	"github.com/opendwellers/jujubot/pkg/metrics"
+	"go.uber.org/zap"
+)
+
+// startHTTPServer serves the health and metrics endpoints on the configured port
+func (b *Bot) startHTTPServer() {
+	if b.config.HealthPort == 0 {
+		return
+	}
+
+	mux := http.NewServeMux()
+	health.Register(mux, b, b.config.LivenessTimeout)
+	mux.Handle("/metrics", metrics.Handler())
+
+	b.httpServer = &http.Server{
+		Addr:              ":" + strconv.Itoa(b.config.HealthPort),
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		zap.S().Info("Serving health and metrics endpoints on " + b.httpServer.Addr)
+		if err := b.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			zap.S().Error("Health server failed", zap.Error(err))
+		}
+	}()
+}
+
+// stopHTTPServer shuts the health server down
+func (b *Bot) stopHTTPServer() {
+	if b.httpServer == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := b.httpServer.Shutdown(ctx); err != nil {
+		zap.S().Error("Failed to stop health server", zap.Error(err))
+	}
+}
+
+// HealthStatus reports the bot's current health
+func (b *Bot) HealthStatus() health.Status {
+	state := b.conn.getState()
+	status := health.Status{
+		Authenticated: b.authenticated.Load(),
+		TeamResolved:  b.teamResolved.Load(),
+		Connection:    state.String(),
+		Connected:     state == stateConnected,
+	}
+	if since := b.conn.stateSince.Load(); since != 0 {
+		status.ConnectionSince = time.Unix(0, since)
+	}
+	if last := b.conn.lastEventAt.Load(); last != 0 {
+		status.LastEventAt = time.Unix(0, last)
+	}
+	return status
+}
+
+// setConnState updates the connection state and the readiness file with it
+func (b *Bot) setConnState(state connState) {
+	b.conn.setState(state)
+	b.syncReadinessFile()
+}
+
+// syncReadinessFile creates the readiness probe file while the bot is ready
+// and removes it otherwise. It is kept for deployments probing the file
+// instead of the HTTP endpoints.
+func (b *Bot) syncReadinessFile() {
+	path := b.config.ReadinessFile
+	if path == "" {
+		return
+	}
+
+	if !b.HealthStatus().Ready() {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			zap.S().Error("Failed to remove "+path, zap.Error(err))
+		}
+		return
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		zap.S().Error("Failed to create "+path, zap.Error(err))
+		return
+	}
+	_ = f.Close()
+}
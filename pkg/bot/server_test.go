@@ -0,0 +1,46 @@
+package bot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opendwellers/jujubot/pkg/config"
+)
+
+func TestReadinessFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ready")
+	b, _ := newTestBot(t, config.Config{ReadinessFile: path})
+	// newTestBot logs in
+	b.authenticated.Store(false)
+
+	steps := []struct {
+		name          string
+		authenticated bool
+		teamResolved  bool
+		state         connState
+		wantReady     bool
+	}{
+		{"not authenticated", false, true, stateConnected, false},
+		{"team unresolved", true, false, stateConnected, false},
+		{"connecting", true, true, stateConnecting, false},
+		{"ready", true, true, stateConnected, true},
+		{"disconnected", true, true, stateDisconnected, false},
+		{"reconnected", true, true, stateConnected, true},
+		{"backoff", true, true, stateBackoff, false},
+	}
+	for _, step := range steps {
+		b.authenticated.Store(step.authenticated)
+		b.teamResolved.Store(step.teamResolved)
+		b.setConnState(step.state)
+
+		status := b.HealthStatus()
+		if status.Ready() != step.wantReady || status.Connection != step.state.String() {
+			t.Errorf("%s: got %+v, want ready %v", step.name, status, step.wantReady)
+		}
+		_, err := os.Stat(path)
+		if exists := err == nil; exists != step.wantReady {
+			t.Errorf("%s: readiness file exists: %v, want %v", step.name, exists, step.wantReady)
+		}
+	}
+}
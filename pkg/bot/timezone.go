@@ -0,0 +1,55 @@
+package bot
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// lastTimezone is the last time zone to leave a given day, UTC-12. A day is
+// over everywhere once it is over there.
+var lastTimezone = time.FixedZone("UTC-12", -12*60*60)
+
+// loadDefaultLocation returns the configured default time zone, or the
+// server's local one when not set
+func loadDefaultLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid default timezone")
+	}
+	return loc, nil
+}
+
+// userLocation returns the time zone set in the user's Mattermost profile,
+// falling back to the default time zone
+func (b *Bot) userLocation(userId string) *time.Location {
+	user, err := b.users.Get(context.TODO(), userId)
+	if err != nil {
+		return b.location
+	}
+
+	name := user.GetPreferredTimezone()
+	if name == "" {
+		return b.location
+	}
+	if loc, ok := b.locations.Load(name); ok {
+		return loc.(*time.Location)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		zap.S().Warn("Unknown timezone "+name+" for user "+user.Username, zap.Error(err))
+		return b.location
+	}
+	b.locations.Store(name, loc)
+	return loc
+}
+
+// userNow returns the current time in the user's time zone
+func (b *Bot) userNow(userId string) time.Time {
+	return b.clock.Now().In(b.userLocation(userId))
+}
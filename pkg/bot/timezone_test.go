@@ -0,0 +1,98 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/opendwellers/jujubot/pkg/config"
+	"github.com/opendwellers/jujubot/pkg/store"
+)
+
+// newTimezoneBot creates a bot defaulting to defaultTimezone and knowing
+// users in Montreal and Tokyo, one without a time zone and one with an
+// invalid one
+func newTimezoneBot(t *testing.T, defaultTimezone string, now time.Time) *Bot {
+	t.Helper()
+	b, client := newTestBot(t, config.Config{DefaultTimezone: defaultTimezone}, WithClock(NewFakeClock(now)), WithRandom(NewScriptedRandom(99)))
+	client.AddUser(&model.User{Id: "montreal", Username: "montreal", Timezone: model.StringMap{"useAutomaticTimezone": "false", "manualTimezone": "America/Montreal"}})
+	client.AddUser(&model.User{Id: "tokyo", Username: "tokyo", Timezone: model.StringMap{"useAutomaticTimezone": "true", "automaticTimezone": "Asia/Tokyo"}})
+	client.AddUser(&model.User{Id: "none", Username: "none"})
+	client.AddUser(&model.User{Id: "invalid", Username: "invalid", Timezone: model.StringMap{"useAutomaticTimezone": "false", "manualTimezone": "Mars/Olympus_Mons"}})
+	return b
+}
+
+func TestUserNow(t *testing.T) {
+	tests := []struct {
+		name            string
+		defaultTimezone string
+		now             time.Time
+		userId          string
+		want            string
+	}{
+		{"Montreal in winter", "UTC", time.Date(2026, time.January, 15, 12, 0, 0, 0, time.UTC), "montreal", "2026-01-15 07:00 EST"},
+		{"Montreal in summer", "UTC", time.Date(2026, time.July, 15, 12, 0, 0, 0, time.UTC), "montreal", "2026-07-15 08:00 EDT"},
+		{"Montreal before spring forward", "UTC", time.Date(2026, time.March, 8, 6, 59, 0, 0, time.UTC), "montreal", "2026-03-08 01:59 EST"},
+		{"Montreal after spring forward", "UTC", time.Date(2026, time.March, 8, 7, 0, 0, 0, time.UTC), "montreal", "2026-03-08 03:00 EDT"},
+		{"Montreal before fall back", "UTC", time.Date(2026, time.November, 1, 5, 59, 0, 0, time.UTC), "montreal", "2026-11-01 01:59 EDT"},
+		{"Montreal after fall back", "UTC", time.Date(2026, time.November, 1, 6, 0, 0, 0, time.UTC), "montreal", "2026-11-01 01:00 EST"},
+		{"Tokyo", "UTC", time.Date(2026, time.January, 15, 20, 0, 0, 0, time.UTC), "tokyo", "2026-01-16 05:00 JST"},
+		{"Tokyo has no DST", "UTC", time.Date(2026, time.July, 15, 20, 0, 0, 0, time.UTC), "tokyo", "2026-07-16 05:00 JST"},
+		{"no time zone", "America/Montreal", time.Date(2026, time.January, 15, 12, 0, 0, 0, time.UTC), "none", "2026-01-15 07:00 EST"},
+		{"invalid time zone", "Asia/Tokyo", time.Date(2026, time.January, 15, 12, 0, 0, 0, time.UTC), "invalid", "2026-01-15 21:00 JST"},
+		{"unknown user", "Asia/Tokyo", time.Date(2026, time.January, 15, 12, 0, 0, 0, time.UTC), "ghost", "2026-01-15 21:00 JST"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTimezoneBot(t, tt.defaultTimezone, tt.now)
+			if got := b.userNow(tt.userId).Format("2006-01-02 15:04 MST"); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUser420(t *testing.T) {
+	tests := []struct {
+		name            string
+		defaultTimezone string
+		now             time.Time
+		userId          string
+		// want is the roll of a 420 die, 100 with the scripted draw
+		want string
+		// wantIs420 tells whether it's 4/20 for the user
+		wantIs420 bool
+	}{
+		{"Montreal 4:20", "UTC", time.Date(2026, time.January, 15, 9, 20, 0, 0, time.UTC), "montreal", "100 :chuckles:", false},
+		{"Montreal 4:20 pm", "UTC", time.Date(2026, time.January, 15, 21, 20, 59, 0, time.UTC), "montreal", "100 :chuckles:", false},
+		{"Montreal 4:20 in summer", "UTC", time.Date(2026, time.July, 15, 8, 20, 0, 0, time.UTC), "montreal", "100 :chuckles:", false},
+		{"Montreal 4:20 UTC", "UTC", time.Date(2026, time.January, 15, 4, 20, 0, 0, time.UTC), "montreal", "Spa leur smh", false},
+		{"Montreal spring forward day 4:20", "UTC", time.Date(2026, time.March, 8, 8, 20, 0, 0, time.UTC), "montreal", "100 :chuckles:", false},
+		{"Montreal spring forward day standard time", "UTC", time.Date(2026, time.March, 8, 9, 20, 0, 0, time.UTC), "montreal", "Spa leur smh", false},
+		{"Montreal fall back day 4:20", "UTC", time.Date(2026, time.November, 1, 9, 20, 0, 0, time.UTC), "montreal", "100 :chuckles:", false},
+		{"Montreal fall back day daylight time", "UTC", time.Date(2026, time.November, 1, 8, 20, 0, 0, time.UTC), "montreal", "Spa leur smh", false},
+		{"Montreal 4/20", "UTC", time.Date(2026, time.April, 20, 20, 20, 0, 0, time.UTC), "montreal", "100 + 10 charge bonus = 110 :chuckles:", true},
+		{"Montreal 4/19 while 4/20 in UTC", "UTC", time.Date(2026, time.April, 20, 3, 20, 0, 0, time.UTC), "montreal", "Spa leur smh", false},
+		{"Montreal 4/20 while 4/21 in UTC", "UTC", time.Date(2026, time.April, 21, 3, 20, 0, 0, time.UTC), "montreal", "Spa leur smh", true},
+		{"Tokyo 4:20", "UTC", time.Date(2026, time.January, 15, 19, 20, 0, 0, time.UTC), "tokyo", "100 :chuckles:", false},
+		{"Tokyo 4/20 while 4/19 in UTC", "UTC", time.Date(2026, time.April, 19, 19, 20, 0, 0, time.UTC), "tokyo", "100 + 10 charge bonus = 110 :chuckles:", true},
+		{"Tokyo 4:20 in Montreal", "UTC", time.Date(2026, time.April, 20, 8, 20, 0, 0, time.UTC), "tokyo", "Spa leur smh", true},
+		{"no time zone uses the default", "America/Montreal", time.Date(2026, time.April, 20, 8, 20, 0, 0, time.UTC), "none", "100 + 10 charge bonus = 110 :chuckles:", true},
+		{"no time zone not 4:20 in UTC", "America/Montreal", time.Date(2026, time.April, 20, 4, 20, 0, 0, time.UTC), "none", "Spa leur smh", true},
+		{"invalid time zone uses the default", "Asia/Tokyo", time.Date(2026, time.April, 19, 19, 20, 0, 0, time.UTC), "invalid", "100 + 10 charge bonus = 110 :chuckles:", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTimezoneBot(t, tt.defaultTimezone, tt.now)
+			if err := store.Put(b.store, chargeCollection, tt.userId, 10); err != nil {
+				t.Fatal(err)
+			}
+			if got := is420(b.userNow(tt.userId)); got != tt.wantIs420 {
+				t.Errorf("is420 = %v, want %v", got, tt.wantIs420)
+			}
+			if got := b.rollDice(420, tt.userId); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package bot
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"github.com/opendwellers/jujubot/pkg/config"
+	"github.com/opendwellers/jujubot/pkg/normalize"
+	"github.com/opendwellers/jujubot/pkg/store"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	// triggersCollection stores the learned triggers, keyed by ID
+	triggersCollection = "triggers"
+
+	maxTriggerLength  = 100
+	maxResponseLength = 500
+	maxTriggers       = 200
+)
+
+// massMention matches the mentions notifying a whole channel
+var massMention = regexp.MustCompile(`(?i)@(?:channel|all|here)\b`)
+
+// learnedTrigger is a reaction taught in chat with "when someone says X say Y"
+type learnedTrigger struct {
+	Id int `json:"id"`
+	// Trigger is the text as typed, a regular expression when within slashes
+	Trigger   string    `json:"trigger"`
+	Response  string    `json:"response"`
+	AuthorId  string    `json:"author_id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	re *regexp.Regexp
+}
+
+// compile checks the trigger and builds its pattern. Literal triggers match
+// whole words, ignoring case and accents like the normalized messages.
+func (t *learnedTrigger) compile() error {
+	if utf8.RuneCountInString(t.Trigger) > maxTriggerLength {
+		return errors.Errorf("trigger is longer than %d characters", maxTriggerLength)
+	}
+	if utf8.RuneCountInString(t.Response) > maxResponseLength {
+		return errors.Errorf("response is longer than %d characters", maxResponseLength)
+	}
+	if m := massMention.FindString(t.Response); m != "" {
+		return errors.Errorf("no %s in responses", m)
+	}
+
+	pattern := `(?:^|\W)` + regexp.QuoteMeta(normalize.StripDiacritics(t.Trigger)) + `(?:\W|$)`
+	if expr, ok := t.regex(); ok {
+		// Messages are matched without accents, so the expression is too
+		pattern = normalize.StripDiacritics(expr)
+	}
+	re, err := regexp.Compile(globalRegexOptions + pattern)
+	if err != nil {
+		return errors.Wrap(err, "invalid regex")
+	}
+	if re.MatchString("") {
+		return errors.New("trigger matches every message")
+	}
+	t.re = re
+	return nil
+}
+
+// regex returns the expression of a /regex/ trigger
+func (t *learnedTrigger) regex() (string, bool) {
+	if len(t.Trigger) > 2 && strings.HasPrefix(t.Trigger, "/") && strings.HasSuffix(t.Trigger, "/") {
+		return t.Trigger[1 : len(t.Trigger)-1], true
+	}
+	return "", false
+}
+
+// learnedTriggersName is the reaction name of the learned triggers, in
+// metrics and limit overrides
+const learnedTriggersName = "learned"
+
+// learnedTriggers holds the compiled triggers
+type learnedTriggers struct {
+	mu       sync.Mutex
+	triggers []*learnedTrigger
+}
+
+// loadTriggers compiles the stored triggers, skipping invalid ones
+func (b *Bot) loadTriggers() error {
+	var triggers []*learnedTrigger
+	err := b.store.View(func(tx store.Tx) error {
+		for _, key := range tx.Keys(triggersCollection) {
+			var t learnedTrigger
+			if _, err := tx.Get(triggersCollection, key, &t); err != nil {
+				return err
+			}
+			if err := t.compile(); err != nil {
+				zap.S().Warn("Skipping invalid learned trigger "+key, zap.Error(err))
+				continue
+			}
+			triggers = append(triggers, &t)
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to load learned triggers")
+	}
+
+	sort.Slice(triggers, func(i, j int) bool {
+		return triggers[i].Id < triggers[j].Id
+	})
+	b.triggers.mu.Lock()
+	defer b.triggers.mu.Unlock()
+	b.triggers.triggers = triggers
+	return nil
+}
+
+// handleLearnedTriggers answers with the first learned trigger matching the
+// normalized text of the message that isn't held back by its limits
+func (b *Bot) handleLearnedTriggers(post *model.Post, text, replyToId string) bool {
+	limit := b.reactionLimit(learnedTriggersName, post.ChannelId, config.ReactionLimit{Cooldown: &b.config.TriggerCooldown})
+
+	b.triggers.mu.Lock()
+	var fired *learnedTrigger
+	for _, t := range b.triggers.triggers {
+		if t.re.MatchString(text) && b.limiter.allow(learnedTriggersName+"#"+strconv.Itoa(t.Id), post.ChannelId, limit) {
+			fired = t
+			break
+		}
+	}
+	b.triggers.mu.Unlock()
+
+	if fired == nil {
+		return false
+	}
+
+	response := fired.Response
+	if _, ok := fired.regex(); ok {
+		response = string(fired.re.ExpandString(nil, response, text, fired.re.FindStringSubmatchIndex(text)))
+		// Captured text can't notify the whole channel either. Mentions in
+		// code don't notify.
+		response = massMention.ReplaceAllString(response, "`$0`")
+	}
+	b.createPost(post.ChannelId, response, replyToId)
+	reactionsTotal.Inc(learnedTriggersName)
+	return true
+}
+
+// handleTeachCommand learns a new trigger
+func (b *Bot) handleTeachCommand(req *CommandRequest) {
+	post := req.Post
+	t := learnedTrigger{
+		Trigger:   strings.TrimSpace(req.Args[1]),
+		Response:  strings.TrimSpace(req.Args[2]),
+		AuthorId:  post.UserId,
+		CreatedAt: b.clock.Now(),
+	}
+	if err := t.compile(); err != nil {
+		b.createReply(post.ChannelId, "Nope: "+err.Error(), req.ReplyToId, post.UserId)
+		return
+	}
+
+	err := b.store.Update(func(tx store.Tx) error {
+		keys := tx.Keys(triggersCollection)
+		if len(keys) >= maxTriggers {
+			return errors.Errorf("I already know %d triggers, forget some first", maxTriggers)
+		}
+		for _, key := range keys {
+			id, _ := strconv.Atoi(key)
+			t.Id = max(t.Id, id)
+		}
+		t.Id++
+		return tx.Put(triggersCollection, strconv.Itoa(t.Id), t)
+	})
+	if err != nil {
+		zap.S().Error("Failed to save learned trigger", zap.Error(err))
+		b.createReply(post.ChannelId, "Nope: "+err.Error(), req.ReplyToId, post.UserId)
+		return
+	}
+
+	b.triggers.mu.Lock()
+	b.triggers.triggers = append(b.triggers.triggers, &t)
+	b.triggers.mu.Unlock()
+
+	b.createReply(post.ChannelId, fmt.Sprintf("ok! (#%d)", t.Id), req.ReplyToId, post.UserId)
+}
+
+// handleTriggersCommand lists the learned triggers
+func (b *Bot) handleTriggersCommand(req *CommandRequest) {
+	post := req.Post
+
+	b.triggers.mu.Lock()
+	triggers := append([]*learnedTrigger(nil), b.triggers.triggers...)
+	b.triggers.mu.Unlock()
+
+	if len(triggers) == 0 {
+		b.createPost(post.ChannelId, "I haven't learned anything yet. Try `@"+b.user.Username+" when someone says X say Y`.", req.ReplyToId)
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("#### Learned triggers\n")
+	for _, t := range triggers {
+		fmt.Fprintf(&sb, "- #%d `%s` → %s _(%s, %s)_\n", t.Id, t.Trigger, t.Response,
+			b.getUserMention(t.AuthorId), t.CreatedAt.Format(time.DateOnly))
+	}
+	b.createPost(post.ChannelId, sb.String(), req.ReplyToId)
+}
+
+// handleForgetCommand removes a learned trigger. Only its author and the
+// admins can remove it.
+func (b *Bot) handleForgetCommand(req *CommandRequest) {
+	post := req.Post
+	id, _ := strconv.Atoi(req.Args[1])
+
+	b.triggers.mu.Lock()
+	var found *learnedTrigger
+	for _, t := range b.triggers.triggers {
+		if t.Id == id {
+			found = t
+		}
+	}
+	b.triggers.mu.Unlock()
+
+	if found == nil {
+		b.createReply(post.ChannelId, fmt.Sprintf("I don't know any trigger #%d", id), req.ReplyToId, post.UserId)
+		return
+	}
+	if found.AuthorId != post.UserId && !b.isAdmin(post.UserId) {
+		b.createReply(post.ChannelId, "lol no, only its author can make me forget that", req.ReplyToId, post.UserId)
+		return
+	}
+
+	if err := store.Delete(b.store, triggersCollection, strconv.Itoa(id)); err != nil {
+		zap.S().Error("Failed to delete learned trigger", zap.Error(err))
+		return
+	}
+
+	b.triggers.mu.Lock()
+	b.triggers.triggers = slices.DeleteFunc(b.triggers.triggers, func(t *learnedTrigger) bool {
+		return t.Id == id
+	})
+	b.triggers.mu.Unlock()
+
+	b.createReply(post.ChannelId, fmt.Sprintf("forgot #%d `%s`", id, found.Trigger), req.ReplyToId, post.UserId)
+}
@@ -0,0 +1,108 @@
+package bot
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opendwellers/jujubot/pkg/config"
+)
+
+func TestLearnedTriggerCompile(t *testing.T) {
+	tests := []struct {
+		name     string
+		trigger  string
+		response string
+		wantErr  string
+		match    []string
+		noMatch  []string
+	}{
+		{name: "literal", trigger: "poutine", response: "miam", match: []string{"poutine", "une poutine svp"}, noMatch: []string{"poutines", "pou tine"}},
+		{name: "literal ignores accents", trigger: "Crème brûlée", response: "miam", match: []string{"creme brulee"}},
+		{name: "literal special characters", trigger: "c++", response: "lol", match: []string{"j'aime le c++"}, noMatch: []string{"c"}},
+		{name: "regex", trigger: "/bonne (nuit|soiree)/", response: "$1 a toi aussi", match: []string{"bonne nuit"}, noMatch: []string{"bonne journee"}},
+		{name: "regex ignores accents", trigger: "/café (noir|crème)/", response: "ok", match: []string{"un cafe noir", "cafe creme"}, noMatch: []string{"cafe au lait"}},
+		{name: "longest trigger", trigger: strings.Repeat("a", maxTriggerLength), response: "ok"},
+		{name: "trigger too long", trigger: strings.Repeat("a", maxTriggerLength+1), response: "ok", wantErr: "trigger is longer than 100 characters"},
+		{name: "trigger length counts characters", trigger: strings.Repeat("é", maxTriggerLength), response: "ok"},
+		{name: "longest response", trigger: "a", response: strings.Repeat("a", maxResponseLength)},
+		{name: "response too long", trigger: "a", response: strings.Repeat("a", maxResponseLength+1), wantErr: "response is longer than 500 characters"},
+		{name: "invalid regex", trigger: "/bonne (nuit/", response: "ok", wantErr: "invalid regex"},
+		{name: "regex matching everything", trigger: "/.*/", response: "ok", wantErr: "trigger matches every message"},
+		{name: "@channel", trigger: "a", response: "hey @channel", wantErr: "no @channel in responses"},
+		{name: "@all", trigger: "a", response: "@ALL go", wantErr: "no @ALL in responses"},
+		{name: "@here", trigger: "a", response: "@here!", wantErr: "no @here in responses"},
+		{name: "user mention", trigger: "a", response: "@alice hey"},
+		{name: "username starting like a mass mention", trigger: "a", response: "@heres_johnny hey"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trigger := learnedTrigger{Trigger: tt.trigger, Response: tt.response}
+			err := trigger.compile()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, text := range tt.match {
+				if !trigger.re.MatchString(text) {
+					t.Errorf("%q doesn't match", text)
+				}
+			}
+			for _, text := range tt.noMatch {
+				if trigger.re.MatchString(text) {
+					t.Errorf("%q matches", text)
+				}
+			}
+		})
+	}
+}
+
+func TestLearnedTriggerCooldown(t *testing.T) {
+	b, client := newTestBot(t, config.Config{TriggerCooldown: time.Minute})
+	clock := b.clock.(*FakeClock)
+	say(b, "alice", "@jujubot when someone says zorglub say zorglonde")
+	client.Reset()
+
+	say(b, "bob", "zorglub")
+	say(b, "bob", "zorglub encore")
+	clock.Advance(59 * time.Second)
+	say(b, "bob", "zorglub toujours")
+	clock.Advance(time.Second)
+	say(b, "bob", "zorglub enfin")
+
+	want := []string{"zorglonde", "zorglonde"}
+	if got := postedMessages(client); !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestLearnedTriggerMassMention(t *testing.T) {
+	b, client := newTestBot(t, config.Config{})
+	say(b, "alice", "@jujubot when someone says zorglub say @here regardez")
+	say(b, "alice", "@jujubot when someone says /crie (.*)/ say $1!!")
+	say(b, "bob", "zorglub")
+	say(b, "bob", "crie @channel")
+
+	want := []string{"@alice: Nope: no @here in responses", "@alice: ok! (#1)", "`@channel`!!"}
+	if got := postedMessages(client); !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestLearnedRegexTriggerAccents(t *testing.T) {
+	b, client := newTestBot(t, config.Config{})
+	say(b, "alice", "@jujubot when someone says /un café (\\w+)/ say $1 pour moi aussi")
+	client.Reset()
+	say(b, "bob", "un café allongé svp")
+
+	want := []string{"allonge pour moi aussi"}
+	if got := postedMessages(client); !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,128 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+	"go.uber.org/zap"
+)
+
+// userDirectory caches users by ID and username. Entries expire after a TTL
+// and are refreshed by user_updated events.
+type userDirectory struct {
+	client ChatClient
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu         sync.Mutex
+	byId       map[string]cachedUser
+	byUsername map[string]string // lowercase username -> user ID
+}
+
+type cachedUser struct {
+	user      *model.User
+	fetchedAt time.Time
+}
+
+func newUserDirectory(client ChatClient, ttl time.Duration, now func() time.Time) *userDirectory {
+	return &userDirectory{
+		client:     client,
+		ttl:        ttl,
+		now:        now,
+		byId:       make(map[string]cachedUser),
+		byUsername: make(map[string]string),
+	}
+}
+
+// Get returns the user with the given ID, fetching it if it isn't cached
+func (d *userDirectory) Get(ctx context.Context, userId string) (*model.User, error) {
+	if user, ok := d.cached(userId); ok {
+		return user, nil
+	}
+
+	user, err := d.client.GetUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	d.Put(user)
+	return user, nil
+}
+
+// GetByUsername returns the user with the given username, with or without
+// the leading @, fetching it if it isn't cached
+func (d *userDirectory) GetByUsername(ctx context.Context, username string) (*model.User, error) {
+	username = strings.ToLower(strings.TrimPrefix(username, "@"))
+
+	d.mu.Lock()
+	userId, ok := d.byUsername[username]
+	d.mu.Unlock()
+	if ok {
+		if user, ok := d.cached(userId); ok && strings.EqualFold(user.Username, username) {
+			return user, nil
+		}
+	}
+
+	user, err := d.client.GetUserByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	d.Put(user)
+	return user, nil
+}
+
+// cached returns a user that hasn't expired yet
+func (d *userDirectory) cached(userId string) (*model.User, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	entry, ok := d.byId[userId]
+	if !ok || d.now().Sub(entry.fetchedAt) > d.ttl {
+		return nil, false
+	}
+	return entry.user, true
+}
+
+// Put adds or refreshes a user
+func (d *userDirectory) Put(user *model.User) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Drop the previous username in case it changed
+	if previous, ok := d.byId[user.Id]; ok {
+		delete(d.byUsername, strings.ToLower(previous.user.Username))
+	}
+	d.byId[user.Id] = cachedUser{user: user, fetchedAt: d.now()}
+	d.byUsername[strings.ToLower(user.Username)] = user.Id
+}
+
+// Invalidate forgets a user so the next lookup fetches it again
+func (d *userDirectory) Invalidate(userId string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if previous, ok := d.byId[userId]; ok {
+		delete(d.byUsername, strings.ToLower(previous.user.Username))
+		delete(d.byId, userId)
+	}
+}
+
+// handleUserUpdated refreshes the cache from a user_updated event
+func (d *userDirectory) handleUserUpdated(event *model.WebSocketEvent) {
+	raw, err := json.Marshal(event.GetData()["user"])
+	if err != nil {
+		return
+	}
+
+	var user *model.User
+	if err := json.Unmarshal(raw, &user); err != nil || user == nil || user.Id == "" {
+		zap.S().Debug("Ignoring user_updated event without a user")
+		return
+	}
+
+	// Events carry a sanitized user, so fetch the full one on next use
+	d.Invalidate(user.Id)
+}
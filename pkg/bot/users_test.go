@@ -0,0 +1,117 @@
+package bot
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost/server/public/model"
+)
+
+// countingClient is a FakeClient that counts user fetches
+type countingClient struct {
+	*FakeClient
+	fetches atomic.Int32
+}
+
+func (c *countingClient) GetUser(ctx context.Context, userId string) (*model.User, error) {
+	c.fetches.Add(1)
+	return c.FakeClient.GetUser(ctx, userId)
+}
+
+func (c *countingClient) GetUserByUsername(ctx context.Context, username string) (*model.User, error) {
+	c.fetches.Add(1)
+	return c.FakeClient.GetUserByUsername(ctx, username)
+}
+
+func newTestDirectory(ttl time.Duration) (*userDirectory, *countingClient, *FakeClock) {
+	client := &countingClient{FakeClient: NewFakeClient(&model.User{Id: "bot", Username: "jujubot"})}
+	client.AddUser(&model.User{Id: "alice", Username: "alice"})
+	clock := NewFakeClock(testNow)
+	return newUserDirectory(client, ttl, clock.Now), client, clock
+}
+
+func TestUserDirectoryTTL(t *testing.T) {
+	ctx := context.Background()
+	users, client, clock := newTestDirectory(time.Minute)
+
+	for _, step := range []struct {
+		name    string
+		advance time.Duration
+		lookup  func() (*model.User, error)
+		fetches int32
+	}{
+		{"first lookup", 0, func() (*model.User, error) { return users.Get(ctx, "alice") }, 1},
+		{"cached by ID", 30 * time.Second, func() (*model.User, error) { return users.Get(ctx, "alice") }, 1},
+		{"cached by username", 0, func() (*model.User, error) { return users.GetByUsername(ctx, "@Alice") }, 1},
+		{"at the TTL", 30 * time.Second, func() (*model.User, error) { return users.Get(ctx, "alice") }, 1},
+		{"past the TTL", time.Second, func() (*model.User, error) { return users.Get(ctx, "alice") }, 2},
+		{"refreshed", 59 * time.Second, func() (*model.User, error) { return users.GetByUsername(ctx, "alice") }, 2},
+		{"username past the TTL", 2 * time.Second, func() (*model.User, error) { return users.GetByUsername(ctx, "alice") }, 3},
+	} {
+		clock.Advance(step.advance)
+		user, err := step.lookup()
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if user.Id != "alice" {
+			t.Errorf("%s: got user %q, want alice", step.name, user.Id)
+		}
+		if got := client.fetches.Load(); got != step.fetches {
+			t.Errorf("%s: got %d fetches, want %d", step.name, got, step.fetches)
+		}
+	}
+}
+
+func TestUserDirectoryUserUpdated(t *testing.T) {
+	ctx := context.Background()
+	users, client, _ := newTestDirectory(time.Hour)
+
+	if _, err := users.Get(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	// alice is renamed and the event carries a sanitized user
+	client.AddUser(&model.User{Id: "alice", Username: "alicia"})
+	event := model.NewWebSocketEvent(model.WebsocketEventUserUpdated, "", "", "", nil, "")
+	event.Add("user", &model.User{Id: "alice", Username: "alicia"})
+	users.handleUserUpdated(event)
+
+	user, err := users.Get(ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Username != "alicia" {
+		t.Errorf("got username %q, want alicia", user.Username)
+	}
+	if got := client.fetches.Load(); got != 2 {
+		t.Errorf("got %d fetches, want 2", got)
+	}
+
+	if _, err := users.GetByUsername(ctx, "alicia"); err != nil {
+		t.Fatal(err)
+	}
+	if got := client.fetches.Load(); got != 2 {
+		t.Errorf("got %d fetches after looking up the new username, want 2", got)
+	}
+	if _, err := users.GetByUsername(ctx, "alice"); err == nil {
+		t.Error("the old username still resolves")
+	}
+}
+
+func TestUserDirectoryIgnoresEmptyEvent(t *testing.T) {
+	ctx := context.Background()
+	users, client, _ := newTestDirectory(time.Hour)
+
+	if _, err := users.Get(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	users.handleUserUpdated(model.NewWebSocketEvent(model.WebsocketEventUserUpdated, "", "", "", nil, ""))
+	if _, err := users.Get(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if got := client.fetches.Load(); got != 1 {
+		t.Errorf("got %d fetches, want 1", got)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const baseUrl = "https://frankfurter.app/latest"
@@ -14,7 +15,9 @@ type apiResponse struct {
 	Rates map[string]float64 `json:"rates"`
 }
 
-func Convert(from, to string, amount float64) (float64, error) {
+func Convert(from, to string, amount float64) (value float64, err error) {
+	defer observeUpstream("frankfurter", time.Now(), &err)
+
 	to = strings.ToUpper(to)
 	from = strings.ToUpper(from)
 	url := baseUrl + "?from=" + from + "&to=" + to + "&amount=" + strconv.FormatFloat(amount, 'f', 2, 64)
@@ -0,0 +1,187 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxDice     = 100
+	maxSides    = 1000
+	maxModifier = 10000
+	// maxExplosions caps the extra dice rolled by exploding dice
+	maxExplosions = 100
+)
+
+var diceNotation = regexp.MustCompile(`(?i)^(\d*)d(\d+|%)(!)?(?:k(h|l)?(\d+))?(?:\s*([+-])\s*(\d+))?(?:\s+(adv|advantage|dis|disadvantage))?$`)
+
+// DiceRoll is a roll in dice notation: 2d6+3, 4d6kh3, d20 adv or 3d6!
+type DiceRoll struct {
+	Count int
+	Sides int
+	// Modifier is added to the total
+	Modifier int
+	// Keep, when not 0, is the number of dice counted in the total, the
+	// highest ones unless KeepLowest is set
+	Keep       int
+	KeepLowest bool
+	// Explode rolls one more die every time a die rolls its maximum
+	Explode bool
+}
+
+// Die is a single rolled die
+type Die struct {
+	Value int
+	// Kept tells whether the die counts in the total
+	Kept bool
+	// Exploded tells whether the die rolled its maximum and triggered another
+	Exploded bool
+}
+
+// DiceResult is the outcome of a DiceRoll
+type DiceResult struct {
+	Roll  DiceRoll
+	Dice  []Die
+	Total int
+}
+
+// ParseDice parses dice notation. adv and dis roll two dice and keep the
+// highest or lowest one.
+func ParseDice(notation string) (DiceRoll, error) {
+	m := diceNotation.FindStringSubmatch(strings.TrimSpace(notation))
+	if m == nil {
+		return DiceRoll{}, errors.New("I only understand dice like `2d6+3`, `4d6kh3`, `d20 adv` or `3d6!`")
+	}
+
+	roll := DiceRoll{Count: 1, Explode: m[3] != ""}
+	var err error
+	if m[1] != "" {
+		if roll.Count, err = strconv.Atoi(m[1]); err != nil || roll.Count < 1 || roll.Count > maxDice {
+			return DiceRoll{}, fmt.Errorf("you can roll between 1 and %d dice", maxDice)
+		}
+	}
+	if m[2] == "%" {
+		roll.Sides = 100
+	} else if roll.Sides, err = strconv.Atoi(m[2]); err != nil || roll.Sides < 1 || roll.Sides > maxSides {
+		return DiceRoll{}, fmt.Errorf("dice have between 1 and %d sides", maxSides)
+	}
+	if roll.Explode && roll.Sides < 2 {
+		return DiceRoll{}, errors.New("a one-sided die would explode forever")
+	}
+
+	if m[5] != "" {
+		roll.KeepLowest = strings.EqualFold(m[4], "l")
+		if roll.Keep, err = strconv.Atoi(m[5]); err != nil || roll.Keep < 1 || roll.Keep > roll.Count {
+			return DiceRoll{}, fmt.Errorf("you can keep between 1 and %d dice", roll.Count)
+		}
+	}
+
+	if m[7] != "" {
+		if roll.Modifier, err = strconv.Atoi(m[7]); err != nil || roll.Modifier > maxModifier {
+			return DiceRoll{}, fmt.Errorf("the modifier can't be more than %d", maxModifier)
+		}
+		if m[6] == "-" {
+			roll.Modifier = -roll.Modifier
+		}
+	}
+
+	if m[8] != "" {
+		if roll.Count != 1 || roll.Keep != 0 {
+			return DiceRoll{}, errors.New("advantage and disadvantage only work with a single die, like `d20 adv`")
+		}
+		roll.Count = 2
+		roll.Keep = 1
+		roll.KeepLowest = strings.HasPrefix(strings.ToLower(m[8]), "dis")
+	}
+	return roll, nil
+}
+
+// Roll rolls the dice, drawing each die from intn, which returns a number
+// in [0, n)
+func (r DiceRoll) Roll(intn func(n int) int) DiceResult {
+	result := DiceResult{Roll: r}
+	explosions := 0
+	for i := 0; i < r.Count; i++ {
+		die := Die{Value: intn(r.Sides) + 1, Kept: true}
+		result.Dice = append(result.Dice, die)
+
+		// Exploded dice add to the same pool
+		for r.Explode && die.Value == r.Sides && explosions < maxExplosions {
+			result.Dice[len(result.Dice)-1].Exploded = true
+			die = Die{Value: intn(r.Sides) + 1, Kept: true}
+			result.Dice = append(result.Dice, die)
+			explosions++
+		}
+	}
+
+	if r.Keep > 0 && r.Keep < len(result.Dice) {
+		order := make([]int, len(result.Dice))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(i, j int) bool {
+			if r.KeepLowest {
+				return result.Dice[order[i]].Value < result.Dice[order[j]].Value
+			}
+			return result.Dice[order[i]].Value > result.Dice[order[j]].Value
+		})
+		for _, i := range order[r.Keep:] {
+			result.Dice[i].Kept = false
+		}
+	}
+
+	result.Total = r.Modifier
+	for _, die := range result.Dice {
+		if die.Kept {
+			result.Total += die.Value
+		}
+	}
+	return result
+}
+
+// String shows the dice notation of the roll
+func (r DiceRoll) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%dd%d", r.Count, r.Sides)
+	if r.Explode {
+		sb.WriteString("!")
+	}
+	if r.Keep > 0 {
+		if r.KeepLowest {
+			fmt.Fprintf(&sb, "kl%d", r.Keep)
+		} else {
+			fmt.Fprintf(&sb, "kh%d", r.Keep)
+		}
+	}
+	if r.Modifier != 0 {
+		fmt.Fprintf(&sb, "%+d", r.Modifier)
+	}
+	return sb.String()
+}
+
+// String shows every die and the total, e.g. "4d6kh3: [6, ~~1~~, 3, 4] = **13**".
+// Dropped dice are struck through and exploded ones marked with "!".
+func (r DiceResult) String() string {
+	dice := make([]string, len(r.Dice))
+	for i, die := range r.Dice {
+		dice[i] = strconv.Itoa(die.Value)
+		if die.Exploded {
+			dice[i] += "!"
+		}
+		if !die.Kept {
+			dice[i] = "~~" + dice[i] + "~~"
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(r.Roll.String() + ": [" + strings.Join(dice, ", ") + "]")
+	if r.Roll.Modifier != 0 {
+		fmt.Fprintf(&sb, " %+d", r.Roll.Modifier)
+	}
+	fmt.Fprintf(&sb, " = **%d**", r.Total)
+	return sb.String()
+}
@@ -0,0 +1,122 @@
+package commands
+
+import "testing"
+
+// rolls returns an intn drawing the given die values in order, then 1s
+func rolls(values ...int) func(n int) int {
+	return func(n int) int {
+		if len(values) == 0 {
+			return 0
+		}
+		v := values[0]
+		values = values[1:]
+		return (v - 1) % n
+	}
+}
+
+func TestParseDice(t *testing.T) {
+	tests := []struct {
+		notation string
+		want     DiceRoll
+		wantErr  string
+	}{
+		{notation: "2d6+3", want: DiceRoll{Count: 2, Sides: 6, Modifier: 3}},
+		{notation: "2D6 - 3", want: DiceRoll{Count: 2, Sides: 6, Modifier: -3}},
+		{notation: "d20", want: DiceRoll{Count: 1, Sides: 20}},
+		{notation: "d%", want: DiceRoll{Count: 1, Sides: 100}},
+		{notation: "4d6kh3", want: DiceRoll{Count: 4, Sides: 6, Keep: 3}},
+		{notation: "4d6k3", want: DiceRoll{Count: 4, Sides: 6, Keep: 3}},
+		{notation: "4d6kl1", want: DiceRoll{Count: 4, Sides: 6, Keep: 1, KeepLowest: true}},
+		{notation: "d20 adv", want: DiceRoll{Count: 2, Sides: 20, Keep: 1}},
+		{notation: "d20+5 advantage", want: DiceRoll{Count: 2, Sides: 20, Keep: 1, Modifier: 5}},
+		{notation: "1d20 dis", want: DiceRoll{Count: 2, Sides: 20, Keep: 1, KeepLowest: true}},
+		{notation: "d20 disadvantage", want: DiceRoll{Count: 2, Sides: 20, Keep: 1, KeepLowest: true}},
+		{notation: "3d6!", want: DiceRoll{Count: 3, Sides: 6, Explode: true}},
+		{notation: "100d1000+10000", want: DiceRoll{Count: 100, Sides: 1000, Modifier: 10000}},
+		{notation: "2d6 lol", wantErr: "I only understand dice like `2d6+3`, `4d6kh3`, `d20 adv` or `3d6!`"},
+		{notation: "d", wantErr: "I only understand dice like `2d6+3`, `4d6kh3`, `d20 adv` or `3d6!`"},
+		{notation: "0d6", wantErr: "you can roll between 1 and 100 dice"},
+		{notation: "101d6", wantErr: "you can roll between 1 and 100 dice"},
+		{notation: "d0", wantErr: "dice have between 1 and 1000 sides"},
+		{notation: "d1001", wantErr: "dice have between 1 and 1000 sides"},
+		{notation: "d1!", wantErr: "a one-sided die would explode forever"},
+		{notation: "4d6kh0", wantErr: "you can keep between 1 and 4 dice"},
+		{notation: "4d6kh5", wantErr: "you can keep between 1 and 4 dice"},
+		{notation: "d6+10001", wantErr: "the modifier can't be more than 10000"},
+		{notation: "2d20 adv", wantErr: "advantage and disadvantage only work with a single die, like `d20 adv`"},
+		{notation: "d20kh1 dis", wantErr: "advantage and disadvantage only work with a single die, like `d20 adv`"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.notation, func(t *testing.T) {
+			got, err := ParseDice(tt.notation)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoll(t *testing.T) {
+	tests := []struct {
+		notation string
+		rolls    []int
+		want     string
+	}{
+		{"2d6+3", []int{2, 5}, "2d6+3: [2, 5] +3 = **10**"},
+		{"2d6-3", []int{1, 1}, "2d6-3: [1, 1] -3 = **-1**"},
+		{"4d6kh3", []int{6, 1, 3, 4}, "4d6kh3: [6, ~~1~~, 3, 4] = **13**"},
+		{"4d6kh3", []int{3, 3, 3, 3}, "4d6kh3: [3, 3, 3, ~~3~~] = **9**"},
+		{"4d6kl1", []int{6, 2, 3, 2}, "4d6kl1: [~~6~~, 2, ~~3~~, ~~2~~] = **2**"},
+		{"d20 adv", []int{4, 17}, "2d20kh1: [~~4~~, 17] = **17**"},
+		{"d20 dis", []int{4, 17}, "2d20kl1: [4, ~~17~~] = **4**"},
+		{"d20+2 adv", []int{20, 20}, "2d20kh1+2: [20, ~~20~~] +2 = **22**"},
+		{"3d6!", []int{6, 6, 2, 3, 6, 1}, "3d6!: [6!, 6!, 2, 3, 6!, 1] = **24**"},
+		{"2d6!kh1", []int{6, 4, 5}, "2d6!kh1: [6!, ~~4~~, ~~5~~] = **6**"},
+		{"d%", []int{42}, "1d100: [42] = **42**"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.notation, func(t *testing.T) {
+			roll, err := ParseDice(tt.notation)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := roll.Roll(rolls(tt.rolls...)).String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRollExplosionCap(t *testing.T) {
+	roll, err := ParseDice("2d6!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every die rolls a 6
+	result := roll.Roll(func(n int) int { return n - 1 })
+
+	if len(result.Dice) != 2+maxExplosions {
+		t.Fatalf("got %d dice, want %d", len(result.Dice), 2+maxExplosions)
+	}
+	exploded := 0
+	for _, die := range result.Dice {
+		if die.Exploded {
+			exploded++
+		}
+	}
+	if exploded != maxExplosions {
+		t.Errorf("got %d exploded dice, want %d", exploded, maxExplosions)
+	}
+	if want := 6 * (2 + maxExplosions); result.Total != want {
+		t.Errorf("got a total of %d, want %d", result.Total, want)
+	}
+}
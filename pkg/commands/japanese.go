@@ -2,6 +2,7 @@ package commands
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/rand"
 	"net/http"
@@ -43,15 +44,20 @@ type Link struct {
 	URL  string `json:"url"`
 }
 
-func GetWotdJapanese() (string, error) {
+func GetWotdJapanese() (message string, err error) {
+	defer observeUpstream("jisho", time.Now(), &err)
+
 	// Get a random generator that stays the same for a given day
 	randomGenerator := rand.New(rand.NewSource(int64(time.Now().YearDay())))
 	wotdUrl := fmt.Sprintf("https://jisho.org/api/v1/search/words?keyword=%%23common&page=%d", randomGenerator.Intn(29)+1)
 	resp, err := http.Get(wotdUrl)
-	if err != nil || resp.StatusCode != 200 {
+	if err != nil {
 		return "", err
 	}
 	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != 200 {
+		return "", errors.New("Invalid response code from jisho: " + resp.Status)
+	}
 
 	var jishoResp JishoResponse
 	err = json.NewDecoder(resp.Body).Decode(&jishoResp)
@@ -60,7 +66,7 @@ func GetWotdJapanese() (string, error) {
 	}
 	random := jishoResp.Data[randomGenerator.Intn(len(jishoResp.Data))]
 
-	message := fmt.Sprintf(`
+	message = fmt.Sprintf(`
 #### Japanese word of the day for %s
 
 # **%s**
@@ -0,0 +1,23 @@
+package commands
+
+import (
+	"time"
+
+	"github.com/opendwellers/jujubot/pkg/metrics"
+)
+
+var (
+	upstreamDuration = metrics.NewHistogramVec("jujubot_upstream_request_duration_seconds",
+		"Duration of calls to upstream APIs.", metrics.DefaultBuckets, "service")
+	upstreamErrors = metrics.NewCounterVec("jujubot_upstream_errors_total",
+		"Failed calls to upstream APIs.", "service")
+)
+
+// observeUpstream records the duration and outcome of an upstream call.
+// It is meant to be deferred with a pointer to the caller's named error.
+func observeUpstream(service string, start time.Time, err *error) {
+	upstreamDuration.Observe(time.Since(start).Seconds(), service)
+	if *err != nil {
+		upstreamErrors.Inc(service)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 const url = "https://api.opendota.com/api/players/"
@@ -39,6 +40,8 @@ type Profile struct {
 }
 
 func GetDotaMMR(steamID int) (mmr DotaMMR, err error) {
+	defer observeUpstream("opendota", time.Now(), &err)
+
 	resp, err := http.Get(url + strconv.Itoa(steamID))
 	if err != nil {
 		return
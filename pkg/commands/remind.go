@@ -0,0 +1,248 @@
+package commands
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// defaultReminderHour is the time of reminders given a day but no time
+	defaultReminderHour = 9
+	// maxDuration keeps durations well below the 292 years time.Duration holds
+	maxDuration = 100 * 365 * day
+)
+
+var (
+	relativePrefix = regexp.MustCompile(`(?i)^(?:in|dans)\s+`)
+	durationPart   = regexp.MustCompile(`(?i)^(\d+|an?|une?)\s*(secondes?|seconds?|secs?|minutes?|mins?|heures?|hours?|hrs?|jours?|days?|semaines?|weeks?|s|m|h|j|d|w)`)
+	// durationMinutes is the minutes right after hours, as in 2h30
+	durationMinutes = regexp.MustCompile(`^\d{1,2}\b`)
+	durationHalf    = regexp.MustCompile(`(?i)^\s*(?:et demie?|and a half)\b`)
+	durationJoin    = regexp.MustCompile(`(?i)^\s*(?:,|and|et)?\s*`)
+
+	dayPart = regexp.MustCompile(`(?i)^(today|aujourd'hui|tomorrow|demain|apr[eè]s-demain|after tomorrow|sunday|monday|tuesday|wednesday|thursday|friday|saturday|dimanche|lundi|mardi|mercredi|jeudi|vendredi|samedi|\d{4}-\d{2}-\d{2})\b\s*`)
+	// timePart needs a separator, am/pm or "at" before a bare hour, so that
+	// "tomorrow 3 beers" isn't read as 3 o'clock
+	timePart    = regexp.MustCompile(`(?i)^(?:(?:at|à|a|@)\s*)?(\d{1,2})(?:(:|h)(\d{2})?)?\s*(am|pm)?\b\s*`)
+	timeAt      = regexp.MustCompile(`(?i)^(?:at|à|a|@)\s*`)
+	namedTime   = regexp.MustCompile(`(?i)^(?:(?:at|à|a)\s+)?(noon|midi|midnight|minuit)\b\s*`)
+	messageLead = regexp.MustCompile(`(?i)^(?:to\b|that\b|about\b|of\b|de\b|d'|que\b|qu'|pour\b|:|-)\s*`)
+)
+
+// errTooLong is returned for durations over maxDuration
+var errTooLong = errors.New("that's way too long")
+
+// day is the length of the day and week units. They move the date rather
+// than add 24 hours, so "in 2 days" keeps the time across DST changes.
+const day = 24 * time.Hour
+
+// durationUnit returns the length of a duration unit
+func durationUnit(unit string) time.Duration {
+	unit = strings.ToLower(unit)
+	switch {
+	case strings.HasPrefix(unit, "sem"), unit[0] == 'w':
+		return 7 * day
+	case unit[0] == 's':
+		return time.Second
+	case unit[0] == 'm':
+		return time.Minute
+	case unit[0] == 'h':
+		return time.Hour
+	default:
+		return day
+	}
+}
+
+// weekdays maps English and French day names to days of the week
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+	"dimanche": time.Sunday, "lundi": time.Monday, "mardi": time.Tuesday, "mercredi": time.Wednesday,
+	"jeudi": time.Thursday, "vendredi": time.Friday, "samedi": time.Saturday,
+}
+
+// ParseReminder splits a reminder such as "in 2h30 to stretch" or "demain
+// 9h de sortir les poubelles" into when it's due and what to remind. It
+// understands durations in English and French ("in 10 minutes", "dans 1h et
+// 15 min") and days and times ("at 17:00", "tomorrow 9am", "lundi à 8h30",
+// "2026-12-24 18h"), evaluated in the time zone of now.
+func ParseReminder(text string, now time.Time) (time.Time, string, error) {
+	text = strings.TrimSpace(text)
+
+	var due time.Time
+	var rest string
+	if m := relativePrefix.FindString(text); m != "" {
+		d, r, err := parseDuration(text[len(m):])
+		if err != nil {
+			return time.Time{}, "", err
+		}
+		days := d / day
+		due, rest = now.AddDate(0, 0, int(days)).Add(d-days*day), r
+	} else {
+		d, r, err := parseDayTime(text, now)
+		if err != nil {
+			return time.Time{}, "", err
+		}
+		due, rest = d, r
+	}
+
+	rest = strings.TrimSpace(messageLead.ReplaceAllString(strings.TrimSpace(rest), ""))
+	if rest == "" {
+		return time.Time{}, "", errors.New("remind you of what?")
+	}
+	if !due.After(now) {
+		return time.Time{}, "", errors.New("that's in the past")
+	}
+	return due, rest, nil
+}
+
+// parseDuration reads durations like "2h30", "1 hour and 15 minutes" or
+// "une heure et demie" from the start of text
+func parseDuration(text string) (time.Duration, string, error) {
+	var total time.Duration
+	for {
+		m := durationPart.FindStringSubmatch(text)
+		if m == nil {
+			break
+		}
+		// The unit must end the word, so "5 mangues" isn't 5 minutes
+		if r, _ := utf8.DecodeRuneInString(text[len(m[0]):]); unicode.IsLetter(r) {
+			break
+		}
+
+		unit := durationUnit(m[2])
+		n := 1
+		if unicode.IsDigit(rune(m[1][0])) {
+			var err error
+			if n, err = strconv.Atoi(m[1]); err != nil || time.Duration(n) > maxDuration/unit {
+				return 0, "", errTooLong
+			}
+		}
+		total += time.Duration(n) * unit
+		text = text[len(m[0]):]
+
+		if half := durationHalf.FindString(text); half != "" {
+			total += unit / 2
+			text = text[len(half):]
+		} else if unit == time.Hour {
+			if minutes := durationMinutes.FindString(text); minutes != "" {
+				n, _ := strconv.Atoi(minutes)
+				total += time.Duration(n) * time.Minute
+				text = text[len(minutes):]
+			}
+		}
+		text = text[len(durationJoin.FindString(text)):]
+		if total > maxDuration {
+			return 0, "", errTooLong
+		}
+	}
+
+	if total <= 0 {
+		return 0, "", errors.New("in how long? Try `in 2h30` or `dans 10 minutes`")
+	}
+	return total, text, nil
+}
+
+// parseDayTime reads a day, a time or both, in either order, from the
+// start of text
+func parseDayTime(text string, now time.Time) (time.Time, string, error) {
+	year, month, day := now.Date()
+	hour, minute := defaultReminderHour, 0
+	hasDay, hasTime := false, false
+
+	for range 2 {
+		if !hasDay {
+			if m := dayPart.FindStringSubmatch(text); m != nil {
+				date, err := parseDay(strings.ToLower(m[1]), now)
+				if err != nil {
+					return time.Time{}, "", err
+				}
+				year, month, day = date.Date()
+				hasDay = true
+				text = text[len(m[0]):]
+				continue
+			}
+		}
+		if !hasTime {
+			if m := namedTime.FindStringSubmatch(text); m != nil {
+				hour, minute = 12, 0
+				if name := strings.ToLower(m[1]); name == "midnight" || name == "minuit" {
+					hour = 0
+				}
+				hasTime = true
+				text = text[len(m[0]):]
+				continue
+			}
+			if m := timePart.FindStringSubmatch(text); m != nil && (m[2] != "" || m[4] != "" || timeAt.MatchString(m[0])) {
+				h, mm, err := parseClock(m[1], m[3], strings.ToLower(m[4]))
+				if err != nil {
+					return time.Time{}, "", err
+				}
+				hour, minute = h, mm
+				hasTime = true
+				text = text[len(m[0]):]
+				continue
+			}
+		}
+		break
+	}
+
+	if !hasDay && !hasTime {
+		return time.Time{}, "", errors.New("when? Try `in 2h`, `at 17:00` or `tomorrow 9am`")
+	}
+	due := time.Date(year, month, day, hour, minute, 0, 0, now.Location())
+	// A time alone that already passed today means tomorrow
+	if !hasDay && !due.After(now) {
+		due = time.Date(year, month, day+1, hour, minute, 0, 0, now.Location())
+	}
+	return due, text, nil
+}
+
+// parseDay returns the date of a day name, relative to now
+func parseDay(name string, now time.Time) (time.Time, error) {
+	switch name {
+	case "today", "aujourd'hui":
+		return now, nil
+	case "tomorrow", "demain":
+		return now.AddDate(0, 0, 1), nil
+	case "after tomorrow", "après-demain", "apres-demain":
+		return now.AddDate(0, 0, 2), nil
+	}
+	if weekday, ok := weekdays[name]; ok {
+		// The next one, a week from now when it's today
+		days := (int(weekday)-int(now.Weekday())+6)%7 + 1
+		return now.AddDate(0, 0, days), nil
+	}
+	date, err := time.ParseInLocation(time.DateOnly, name, now.Location())
+	if err != nil {
+		return time.Time{}, errors.New("what day is " + name + "?")
+	}
+	return date, nil
+}
+
+// parseClock validates an hour and minutes, converting 12-hour times
+func parseClock(hour, minute, ampm string) (int, int, error) {
+	h, _ := strconv.Atoi(hour)
+	m := 0
+	if minute != "" {
+		m, _ = strconv.Atoi(minute)
+	}
+	if ampm != "" {
+		if h < 1 || h > 12 {
+			return 0, 0, errors.New(hour + ampm + " isn't a time")
+		}
+		h %= 12
+		if ampm == "pm" {
+			h += 12
+		}
+	}
+	if h > 23 || m > 59 {
+		return 0, 0, errors.New("that's not a time of day")
+	}
+	return h, m, nil
+}
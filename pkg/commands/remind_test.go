@@ -0,0 +1,89 @@
+package commands
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseReminder(t *testing.T) {
+	montreal, err := time.LoadLocation("America/Montreal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A Thursday at noon
+	now := time.Date(2026, time.January, 15, 12, 0, 0, 0, montreal)
+	beforeDST := time.Date(2026, time.March, 7, 12, 0, 0, 0, montreal)
+
+	tests := []struct {
+		text        string
+		now         time.Time
+		wantDue     string
+		wantMessage string
+		wantErr     string
+	}{
+		{text: "in 10 minutes to stretch", wantDue: "2026-01-15 12:10 EST", wantMessage: "stretch"},
+		{text: "in 2h30 to stretch", wantDue: "2026-01-15 14:30 EST", wantMessage: "stretch"},
+		{text: "in 1 hour and 15 minutes: call mom", wantDue: "2026-01-15 13:15 EST", wantMessage: "call mom"},
+		{text: "in an hour and a half about the oven", wantDue: "2026-01-15 13:30 EST", wantMessage: "the oven"},
+		{text: "in 45s check the pasta", wantDue: "2026-01-15 12:00 EST", wantMessage: "check the pasta"},
+		{text: "in 2 weeks that rent is due", wantDue: "2026-01-29 12:00 EST", wantMessage: "rent is due"},
+		{text: "in 2 days water the plants", now: beforeDST, wantDue: "2026-03-09 12:00 EDT", wantMessage: "water the plants"},
+		{text: "dans 10 minutes de sortir", wantDue: "2026-01-15 12:10 EST", wantMessage: "sortir"},
+		{text: "dans 1h et 15 min d'appeler maman", wantDue: "2026-01-15 13:15 EST", wantMessage: "appeler maman"},
+		{text: "dans une heure et demie que le four est chaud", wantDue: "2026-01-15 13:30 EST", wantMessage: "le four est chaud"},
+		{text: "dans 3 jours pour le ménage", wantDue: "2026-01-18 12:00 EST", wantMessage: "le ménage"},
+		{text: "dans 2 semaines de payer le loyer", wantDue: "2026-01-29 12:00 EST", wantMessage: "payer le loyer"},
+		{text: "at 17:00 to leave", wantDue: "2026-01-15 17:00 EST", wantMessage: "leave"},
+		{text: "at 9am coffee", wantDue: "2026-01-16 09:00 EST", wantMessage: "coffee"},
+		{text: "at 5 pm go home", wantDue: "2026-01-15 17:00 EST", wantMessage: "go home"},
+		{text: "at noon lunch", wantDue: "2026-01-16 12:00 EST", wantMessage: "lunch"},
+		{text: "tomorrow 9am dentist", wantDue: "2026-01-16 09:00 EST", wantMessage: "dentist"},
+		{text: "tomorrow at 12:30pm lunch", wantDue: "2026-01-16 12:30 EST", wantMessage: "lunch"},
+		{text: "tomorrow 3 beers", wantDue: "2026-01-16 09:00 EST", wantMessage: "3 beers"},
+		{text: "thursday lunch", wantDue: "2026-01-22 09:00 EST", wantMessage: "lunch"},
+		{text: "17h30 tomorrow to leave", wantDue: "2026-01-16 17:30 EST", wantMessage: "leave"},
+		{text: "after tomorrow the party", wantDue: "2026-01-17 09:00 EST", wantMessage: "the party"},
+		{text: "à 17h de partir", wantDue: "2026-01-15 17:00 EST", wantMessage: "partir"},
+		{text: "demain 9h de sortir les poubelles", wantDue: "2026-01-16 09:00 EST", wantMessage: "sortir les poubelles"},
+		{text: "demain midi dîner", wantDue: "2026-01-16 12:00 EST", wantMessage: "dîner"},
+		{text: "après-demain minuit la fête", wantDue: "2026-01-17 00:00 EST", wantMessage: "la fête"},
+		{text: "lundi à 8h30 réunion", wantDue: "2026-01-19 08:30 EST", wantMessage: "réunion"},
+		{text: "Vendredi qu'il faut payer", wantDue: "2026-01-16 09:00 EST", wantMessage: "il faut payer"},
+		{text: "2026-12-24 18h souper", wantDue: "2026-12-24 18:00 EST", wantMessage: "souper"},
+		{text: "in 2h", wantErr: "remind you of what?"},
+		{text: "demain", wantErr: "remind you of what?"},
+		{text: "in 5 mangues", wantErr: "in how long? Try `in 2h30` or `dans 10 minutes`"},
+		{text: "yesterday to stretch", wantErr: "when? Try `in 2h`, `at 17:00` or `tomorrow 9am`"},
+		{text: "at 25:00 to stretch", wantErr: "that's not a time of day"},
+		{text: "at 13pm to stretch", wantErr: "13pm isn't a time"},
+		{text: "2026-02-30 to stretch", wantErr: "what day is 2026-02-30?"},
+		{text: "2025-01-01 to stretch", wantErr: "that's in the past"},
+		{text: "today 8h to stretch", wantErr: "that's in the past"},
+		{text: "in 5000 weeks to stretch", wantDue: "2121-11-13 12:00 EST", wantMessage: "stretch"},
+		{text: "in 100000 weeks to stretch", wantErr: "that's way too long"},
+		{text: "in 99999999999999999999 seconds to stretch", wantErr: "that's way too long"},
+		{text: "in 30000 days and 30000 days to stretch", wantErr: "that's way too long"},
+		{text: "in 876000 hours and a half to stretch", wantErr: "that's way too long"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			from := now
+			if !tt.now.IsZero() {
+				from = tt.now
+			}
+			due, message, err := ParseReminder(tt.text, from)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := due.Format("2006-01-02 15:04 MST"); got != tt.wantDue || message != tt.wantMessage {
+				t.Errorf("got %s %q, want %s %q", got, message, tt.wantDue, tt.wantMessage)
+			}
+		})
+	}
+}
@@ -2,11 +2,14 @@ package commands
 
 import (
 	"errors"
+	"time"
 
 	ud "github.com/dpatrie/urbandictionary"
 )
 
 func GetUrbanDictionaryDefinition(term string) (def *ud.Result, err error) {
+	defer observeUpstream("urbandictionary", time.Now(), &err)
+
 	res, err := ud.Query(term)
 	if err != nil {
 		return
@@ -8,36 +8,45 @@ import (
 	owm "github.com/briandowns/openweathermap"
 )
 
+// Weather is safe for concurrent use: every lookup gets its own
+// OpenWeather request object, since those hold the response.
 type Weather struct {
-	apiKey   string
-	current  *owm.CurrentWeatherData
-	forecast *owm.ForecastWeatherData
+	apiKey string
 }
 
 func NewWeatherClient(apiKey string) (weather Weather, err error) {
 	weather.apiKey = apiKey
-	c, err := owm.NewCurrent("C", "en", apiKey)
-	if err != nil {
-		return
-	}
-	f, err := owm.NewForecast("16", "C", "en", apiKey)
-	if err != nil {
+	// Validate the key and settings once up front
+	if _, err = weather.newCurrent(); err != nil {
 		return
 	}
-	weather.current = c
-	weather.forecast = f
+	_, err = weather.newForecast()
 	return
 }
 
+func (w Weather) newCurrent() (*owm.CurrentWeatherData, error) {
+	return owm.NewCurrent("C", "en", w.apiKey)
+}
+
+func (w Weather) newForecast() (*owm.ForecastWeatherData, error) {
+	return owm.NewForecast("16", "C", "en", w.apiKey)
+}
+
 func (w Weather) GetCurrentWeather(location string) (message string, err error) {
-	err = w.current.CurrentByName(location)
+	defer observeUpstream("openweather", time.Now(), &err)
+
+	current, err := w.newCurrent()
+	if err != nil {
+		return
+	}
+	err = current.CurrentByName(location)
 	if err != nil {
 		return
 	}
 
-	temp := strconv.FormatFloat(w.current.Main.Temp, 'f', 0, 64)
-	feel := strconv.FormatFloat(w.current.Main.FeelsLike, 'f', 0, 64)
-	wind := strconv.FormatFloat(w.current.Wind.Speed*3.6, 'f', 1, 64) // convert to km/h
+	temp := strconv.FormatFloat(current.Main.Temp, 'f', 0, 64)
+	feel := strconv.FormatFloat(current.Main.FeelsLike, 'f', 0, 64)
+	wind := strconv.FormatFloat(current.Wind.Speed*3.6, 'f', 1, 64) // convert to km/h
 	// dayT := strconv.FormatFloat(day.Temp.Day, 'f', 0, 64)
 
 	message = fmt.Sprintf(`### Current weather in %s
@@ -45,18 +54,24 @@ func (w Weather) GetCurrentWeather(location string) (message string, err error)
 | Description | Temperature | Feels Like | Humidity | Wind |
 |:--------|:--------|:--------|:--------|:--------|
 | %s | %s °C | %s °C | %d%% | %s km/h |`,
-		w.current.Name,
-		getDescriptionWithIcon(w.current.Weather[0].Icon, w.current.Weather[0].Description),
+		current.Name,
+		getDescriptionWithIcon(current.Weather[0].Icon, current.Weather[0].Description),
 		temp,
 		feel,
-		w.current.Main.Humidity,
+		current.Main.Humidity,
 		wind)
 
 	return
 }
 
 func (w Weather) GetWeather(location string) (message string, err error) {
-	if err = w.forecast.DailyByName(location, 5); err != nil {
+	defer observeUpstream("openweather", time.Now(), &err)
+
+	daily, err := w.newForecast()
+	if err != nil {
+		return "", err
+	}
+	if err = daily.DailyByName(location, 5); err != nil {
 		return "", err
 	}
 
@@ -65,7 +80,7 @@ func (w Weather) GetWeather(location string) (message string, err error) {
 | Day | Description | High | Low | Humidity | Day |
 |:----------|:----------|:----------|:----------|:----------|:----------|`, location)
 
-	forecast := w.forecast.ForecastWeatherJson.(*owm.Forecast16WeatherData)
+	forecast := daily.ForecastWeatherJson.(*owm.Forecast16WeatherData)
 	for _, day := range forecast.List {
 		text := getWeatherLine(day)
 		message += text
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/viper"
@@ -12,16 +13,127 @@ import (
 
 const (
 	ConfigPathKey = "CONFIG_PATH"
+	// ReactionsFileName is the default reactions file, which isn't merged
+	// into the configuration
+	ReactionsFileName = "reactions.yaml"
 )
 
 type Config struct {
-	MattermostHostname string `mapstructure:"mattermost_hostname"`
-	ServerURL          string `mapstructure:"server_url"`
-	ServerWSURL        string `mapstructure:"server_ws_url"`
-	TeamName           string `mapstructure:"team_name"`
-	ChannelLogName     string `mapstructure:"channel_log_name"`
-	AuthToken          string `mapstructure:"auth_token"`
-	OpenWeatherApiKey  string `mapstructure:"open_weather_api_key"`
+	MattermostHostname string                   `mapstructure:"mattermost_hostname"`
+	ServerURL          string                   `mapstructure:"server_url"`
+	ServerWSURL        string                   `mapstructure:"server_ws_url"`
+	TeamName           string                   `mapstructure:"team_name"`
+	ChannelLogName     string                   `mapstructure:"channel_log_name"`
+	AuthToken          string                   `mapstructure:"auth_token"`
+	OpenWeatherApiKey  string                   `mapstructure:"open_weather_api_key"`
+	ShutdownTimeout    time.Duration            `mapstructure:"shutdown_timeout"`
+	HealthPort         int                      `mapstructure:"health_port"`
+	LivenessTimeout    time.Duration            `mapstructure:"liveness_timeout"`
+	ReadinessFile      string                   `mapstructure:"readiness_file"`
+	Admins             []string                 `mapstructure:"admins"`
+	Commands           map[string]CommandConfig `mapstructure:"commands"`
+
+	// StorePath is the JSON file holding the bot's state. State is kept in
+	// memory only when empty.
+	StorePath string `mapstructure:"store_path"`
+
+	// EventWorkers is the number of events handled concurrently. Events of a
+	// channel are always handled in order by the same worker.
+	EventWorkers int `mapstructure:"event_workers"`
+	// EventQueueSize is how many events each worker buffers before the
+	// WebSocket reader waits
+	EventQueueSize int `mapstructure:"event_queue_size"`
+
+	// UserCacheTTL is how long looked up users are cached
+	UserCacheTTL time.Duration `mapstructure:"user_cache_ttl"`
+
+	// ReactionsFile is the YAML file defining the pattern reactions. It is
+	// reloaded on change and defaults to reactions.yaml in the config path.
+	ReactionsFile string `mapstructure:"reactions_file"`
+
+	// TriggerCooldown is the minimum time between two answers of the same
+	// learned trigger in a channel
+	TriggerCooldown time.Duration `mapstructure:"trigger_cooldown"`
+
+	// KarmaCooldown is the minimum time between two karma changes of the same
+	// thing by the same user
+	KarmaCooldown time.Duration `mapstructure:"karma_cooldown"`
+
+	// ReactionLimits overrides the limits of pattern reactions by channel ID,
+	// then by reaction name. The "*" name applies to every reaction and
+	// "learned" to the triggers taught in chat.
+	ReactionLimits map[string]map[string]ReactionLimit `mapstructure:"reaction_limits"`
+
+	// DefaultTimezone is used for users without a time zone in their profile,
+	// e.g. to tell when it's 4:20. The server's time zone is used when empty.
+	DefaultTimezone string `mapstructure:"default_timezone"`
+
+	// Markov imitates a user with a Markov chain trained on their messages
+	Markov MarkovConfig `mapstructure:"markov"`
+
+	// Schedule lists the jobs posting on a cron schedule, e.g. the word of the day
+	Schedule []ScheduledJob `mapstructure:"schedule"`
+}
+
+// ScheduledJob posts to a channel on a cron schedule
+type ScheduledJob struct {
+	// Name identifies the job, defaulting to its kind. It must be unique.
+	Name string `mapstructure:"name"`
+	// Kind is what the job posts: wotd, weather or announce
+	Kind string `mapstructure:"kind"`
+	// Cron is a 5-field cron expression, e.g. "0 7 * * *"
+	Cron string `mapstructure:"cron"`
+	// Timezone the cron expression is evaluated in, the default time zone
+	// when empty
+	Timezone string `mapstructure:"timezone"`
+	// Channel is the ID of the channel to post to
+	Channel string `mapstructure:"channel"`
+	// Location is the place of the weather forecast, Montreal when empty
+	Location string `mapstructure:"location"`
+	// Message is what announce posts
+	Message string `mapstructure:"message"`
+}
+
+// MarkovConfig configures the Markov chatter
+type MarkovConfig struct {
+	// User is the username to imitate. Markov chatter is disabled when empty.
+	User string `mapstructure:"user"`
+	// Order is the number of words each next word depends on
+	Order int `mapstructure:"order"`
+	// Channels lists the channel IDs read when backfilling from history,
+	// every channel of the bot when empty
+	Channels []string `mapstructure:"channels"`
+	// BackfillLimit caps the number of posts read per channel when backfilling
+	BackfillLimit int `mapstructure:"backfill_limit"`
+	// MaxWords caps the length of generated messages
+	MaxWords int `mapstructure:"max_words"`
+	// InterjectionChance is the probability of chiming in on a message that
+	// nothing else answered. 0 disables interjections.
+	InterjectionChance float64 `mapstructure:"interjection_chance"`
+	// Seed makes generated messages reproducible when not 0
+	Seed int64 `mapstructure:"seed"`
+}
+
+// ReactionLimit keeps a pattern reaction from getting spammy. Unset fields
+// mean no limit, so an override can set a cooldown or cap to 0 to lift it.
+type ReactionLimit struct {
+	// Cooldown is the minimum time between two answers in a channel
+	Cooldown *time.Duration `mapstructure:"cooldown"`
+	// Probability is the chance of answering a match, between 0 and 1. The
+	// reaction always answers when unset and never when 0.
+	Probability *float64 `mapstructure:"probability"`
+	// DailyCap is the maximum number of answers per channel per day, 0 for
+	// no cap
+	DailyCap *int `mapstructure:"daily_cap"`
+}
+
+// CommandConfig enables or restricts a named command
+type CommandConfig struct {
+	Disabled bool `mapstructure:"disabled"`
+	// Channels, when set, lists the only channel IDs where the command is enabled
+	Channels []string `mapstructure:"channels"`
+	// DisabledChannels lists channel IDs where the command is disabled
+	DisabledChannels []string `mapstructure:"disabled_channels"`
 }
 
 func LoadConfig() (config Config, err error) {
@@ -32,6 +144,31 @@ func LoadConfig() (config Config, err error) {
 	_ = viper.BindEnv("team_name", "TEAM_NAME")
 	_ = viper.BindEnv("channel_log_name", "CHANNEL_LOG_NAME")
 	_ = viper.BindEnv("auth_token", "BOT_AUTH_TOKEN")
+	_ = viper.BindEnv("shutdown_timeout", "SHUTDOWN_TIMEOUT")
+	_ = viper.BindEnv("health_port", "HEALTH_PORT")
+	_ = viper.BindEnv("liveness_timeout", "LIVENESS_TIMEOUT")
+	_ = viper.BindEnv("readiness_file", "READINESS_FILE")
+	_ = viper.BindEnv("store_path", "STORE_PATH")
+	_ = viper.BindEnv("event_workers", "EVENT_WORKERS")
+	_ = viper.BindEnv("event_queue_size", "EVENT_QUEUE_SIZE")
+	_ = viper.BindEnv("user_cache_ttl", "USER_CACHE_TTL")
+	_ = viper.BindEnv("reactions_file", "REACTIONS_FILE")
+	_ = viper.BindEnv("default_timezone", "DEFAULT_TIMEZONE")
+	_ = viper.BindEnv("markov.user", "MARKOV_USER")
+	_ = viper.BindEnv("markov.seed", "MARKOV_SEED")
+
+	viper.SetDefault("shutdown_timeout", 10*time.Second)
+	viper.SetDefault("health_port", 8080)
+	viper.SetDefault("liveness_timeout", 5*time.Minute)
+	viper.SetDefault("readiness_file", "/tmp/ready")
+	viper.SetDefault("event_workers", 4)
+	viper.SetDefault("event_queue_size", 100)
+	viper.SetDefault("user_cache_ttl", time.Hour)
+	viper.SetDefault("trigger_cooldown", 5*time.Minute)
+	viper.SetDefault("karma_cooldown", time.Minute)
+	viper.SetDefault("markov.order", 2)
+	viper.SetDefault("markov.backfill_limit", 5000)
+	viper.SetDefault("markov.max_words", 30)
 
 	configPath := os.Getenv(ConfigPathKey)
 	if configPath == "" {
@@ -42,6 +179,9 @@ func LoadConfig() (config Config, err error) {
 	files, _ := os.ReadDir(configPath)
 	for _, file := range files {
 		fileName := file.Name()
+		if fileName == ReactionsFileName {
+			continue
+		}
 		lastDotIndex := strings.LastIndex(fileName, ".")
 		if lastDotIndex == -1 {
 			zap.S().Debug("File without extension will be ignored", "filename", fileName)
@@ -67,6 +207,9 @@ func LoadConfig() (config Config, err error) {
 	if config.ServerURL == "" {
 		config.ServerURL = "https://" + config.MattermostHostname
 	}
+	if config.ReactionsFile == "" {
+		config.ReactionsFile = filepath.Join(configPath, ReactionsFileName)
+	}
 	if config.ServerWSURL == "" {
 		config.ServerWSURL = "wss://" + config.MattermostHostname
 	}
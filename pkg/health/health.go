@@ -0,0 +1,99 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// Status is a snapshot of what the bot knows about its own health
+type Status struct {
+	Authenticated bool
+	TeamResolved  bool
+	Connection    string
+	Connected     bool
+	// ConnectionSince is when the bot last connected or disconnected
+	ConnectionSince time.Time
+	LastEventAt     time.Time
+}
+
+// Reporter is implemented by anything that can report a Status
+type Reporter interface {
+	HealthStatus() Status
+}
+
+// Ready reports whether the bot can currently receive and answer messages
+func (s Status) Ready() bool {
+	return s.Authenticated && s.TeamResolved && s.Connected
+}
+
+// Live reports whether the bot is making progress. A bot that has been
+// disconnected for longer than timeout is considered stuck.
+func (s Status) Live(now time.Time, timeout time.Duration) bool {
+	if s.Connected || s.ConnectionSince.IsZero() {
+		return true
+	}
+	return now.Sub(s.ConnectionSince) < timeout
+}
+
+type statusResponse struct {
+	Status                string   `json:"status"`
+	Ready                 bool     `json:"ready"`
+	Live                  bool     `json:"live"`
+	Authenticated         bool     `json:"authenticated"`
+	TeamResolved          bool     `json:"team_resolved"`
+	Connection            string   `json:"connection"`
+	SecondsInConnection   float64  `json:"seconds_in_connection_state"`
+	SecondsSinceLastEvent *float64 `json:"seconds_since_last_event"`
+}
+
+// Register adds /healthz, /readyz and /livez to mux
+func Register(mux *http.ServeMux, reporter Reporter, livenessTimeout time.Duration) {
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		now := time.Now()
+		status := reporter.HealthStatus()
+		resp := statusResponse{
+			Status:        "ok",
+			Ready:         status.Ready(),
+			Live:          status.Live(now, livenessTimeout),
+			Authenticated: status.Authenticated,
+			TeamResolved:  status.TeamResolved,
+			Connection:    status.Connection,
+		}
+		if !status.ConnectionSince.IsZero() {
+			resp.SecondsInConnection = now.Sub(status.ConnectionSince).Seconds()
+		}
+		if !status.LastEventAt.IsZero() {
+			seconds := now.Sub(status.LastEventAt).Seconds()
+			resp.SecondsSinceLastEvent = &seconds
+		}
+
+		code := http.StatusOK
+		if !resp.Ready || !resp.Live {
+			resp.Status = "unavailable"
+			code = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, _ *http.Request) {
+		writeProbe(w, reporter.HealthStatus().Ready())
+	})
+
+	mux.HandleFunc("/livez", func(w http.ResponseWriter, _ *http.Request) {
+		writeProbe(w, reporter.HealthStatus().Live(time.Now(), livenessTimeout))
+	})
+}
+
+// writeProbe answers a probe with a plain text body
+func writeProbe(w http.ResponseWriter, ok bool) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("unavailable\n"))
+		return
+	}
+	_, _ = w.Write([]byte("ok\n"))
+}
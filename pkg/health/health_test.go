@@ -0,0 +1,89 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type staticReporter Status
+
+func (r staticReporter) HealthStatus() Status {
+	return Status(r)
+}
+
+func TestProbes(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name      string
+		status    Status
+		wantReady bool
+		wantLive  bool
+	}{
+		{"starting", Status{Connection: "disconnected"}, false, true},
+		{"not authenticated", Status{TeamResolved: true, Connection: "connected", Connected: true, ConnectionSince: now}, false, true},
+		{"team unresolved", Status{Authenticated: true, Connection: "connected", Connected: true, ConnectionSince: now}, false, true},
+		{"disconnected", Status{Authenticated: true, TeamResolved: true, Connection: "backoff", ConnectionSince: now.Add(-time.Minute)}, false, true},
+		{"disconnected too long", Status{Authenticated: true, TeamResolved: true, Connection: "backoff", ConnectionSince: now.Add(-10 * time.Minute)}, false, false},
+		{"ready", Status{Authenticated: true, TeamResolved: true, Connection: "connected", Connected: true, ConnectionSince: now.Add(-time.Hour), LastEventAt: now}, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			Register(mux, staticReporter(tt.status), 5*time.Minute)
+
+			probes := []struct {
+				path string
+				ok   bool
+			}{
+				{"/readyz", tt.wantReady},
+				{"/livez", tt.wantLive},
+				{"/healthz", tt.wantReady && tt.wantLive},
+			}
+			for _, probe := range probes {
+				rec := httptest.NewRecorder()
+				mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, probe.path, nil))
+				want := http.StatusOK
+				if !probe.ok {
+					want = http.StatusServiceUnavailable
+				}
+				if rec.Code != want {
+					t.Errorf("%s: got %d, want %d", probe.path, rec.Code, want)
+				}
+			}
+		})
+	}
+}
+
+func TestHealthzBody(t *testing.T) {
+	now := time.Now()
+	mux := http.NewServeMux()
+	Register(mux, staticReporter{
+		Authenticated:   true,
+		Connection:      "connected",
+		Connected:       true,
+		ConnectionSince: now.Add(-time.Minute),
+	}, 5*time.Minute)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if got := rec.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("got content type %q", got)
+	}
+
+	var resp statusResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != "unavailable" || resp.Ready || !resp.Live || !resp.Authenticated || resp.TeamResolved || resp.Connection != "connected" {
+		t.Errorf("got %+v", resp)
+	}
+	if resp.SecondsInConnection < 60 || resp.SecondsInConnection > 120 {
+		t.Errorf("got %v seconds in connection state, want about 60", resp.SecondsInConnection)
+	}
+	if resp.SecondsSinceLastEvent != nil {
+		t.Errorf("got %v seconds since the last event, want none", *resp.SecondsSinceLastEvent)
+	}
+}
@@ -0,0 +1,148 @@
+// Package markov generates text from a word-level Markov chain
+package markov
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultOrder is the number of words each next word depends on
+const DefaultOrder = 2
+
+// separator joins the words of a state. Words never contain it since text
+// is split on whitespace.
+const separator = "\x1f"
+
+// Chain maps every sequence of order words (a state) to the words that
+// followed it and how often. An empty word marks the start or end of a text.
+// It is safe for concurrent use.
+type Chain struct {
+	order int
+
+	mu          sync.RWMutex
+	transitions map[string]map[string]int
+}
+
+// New creates an empty chain of the given order
+func New(order int) *Chain {
+	if order < 1 {
+		order = DefaultOrder
+	}
+	return &Chain{
+		order:       order,
+		transitions: make(map[string]map[string]int),
+	}
+}
+
+// Order returns the number of words each next word depends on
+func (c *Chain) Order() int {
+	return c.order
+}
+
+// Len returns the number of states in the chain
+func (c *Chain) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.transitions)
+}
+
+// Train learns from a text and returns the states it changed
+func (c *Chain) Train(text string) []string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return nil
+	}
+
+	// Pad with empty words so texts start and end like the training ones
+	padded := make([]string, 0, len(words)+c.order+1)
+	for range c.order {
+		padded = append(padded, "")
+	}
+	padded = append(append(padded, words...), "")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var changed []string
+	for i := c.order; i < len(padded); i++ {
+		state := strings.Join(padded[i-c.order:i], separator)
+		next, ok := c.transitions[state]
+		if !ok {
+			next = make(map[string]int)
+			c.transitions[state] = next
+		}
+		next[padded[i]]++
+		changed = append(changed, state)
+	}
+	return changed
+}
+
+// State returns a copy of the words following a state and their counts
+func (c *Chain) State(state string) map[string]int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	next := make(map[string]int, len(c.transitions[state]))
+	for word, count := range c.transitions[state] {
+		next[word] = count
+	}
+	return next
+}
+
+// SetState replaces the words following a state, e.g. when loading a chain
+func (c *Chain) SetState(state string, next map[string]int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	copied := make(map[string]int, len(next))
+	for word, count := range next {
+		copied[word] = count
+	}
+	c.transitions[state] = copied
+}
+
+// Generate walks the chain from the start until it reaches an end or
+// maxWords words. The same rng seed and chain always give the same text.
+// It returns an empty string for an empty chain.
+func (c *Chain) Generate(rng *rand.Rand, maxWords int) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	state := make([]string, c.order)
+	var words []string
+	for len(words) < maxWords {
+		word := pick(rng, c.transitions[strings.Join(state, separator)])
+		if word == "" {
+			break
+		}
+		words = append(words, word)
+		state = append(state[1:], word)
+	}
+	return strings.Join(words, " ")
+}
+
+// pick chooses a word with a probability proportional to its count. Words
+// are sorted first since map iteration order is random.
+func pick(rng *rand.Rand, next map[string]int) string {
+	total := 0
+	words := make([]string, 0, len(next))
+	for word, count := range next {
+		words = append(words, word)
+		total += count
+	}
+	if total == 0 {
+		return ""
+	}
+	sort.Strings(words)
+
+	n := rng.Intn(total)
+	for _, word := range words {
+		n -= next[word]
+		if n < 0 {
+			return word
+		}
+	}
+	return ""
+}
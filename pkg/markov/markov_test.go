@@ -0,0 +1,110 @@
+package markov
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+var corpus = []string{
+	"le velo en hiver cest dangereux",
+	"le velo cest la vie",
+	"en hiver je prends le bus",
+	"la vie est belle en hiver",
+}
+
+func trained(order int) *Chain {
+	c := New(order)
+	for _, text := range corpus {
+		c.Train(text)
+	}
+	return c
+}
+
+func TestGenerateSameSeed(t *testing.T) {
+	c := trained(1)
+	for seed := int64(1); seed <= 20; seed++ {
+		first := c.Generate(rand.New(rand.NewSource(seed)), 30)
+		second := c.Generate(rand.New(rand.NewSource(seed)), 30)
+		if first != second {
+			t.Errorf("seed %d: got %q then %q", seed, first, second)
+		}
+		if first == "" {
+			t.Errorf("seed %d: got an empty text", seed)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name     string
+		texts    []string
+		maxWords int
+		want     string
+	}{
+		{"empty chain", nil, 30, ""},
+		{"single text", []string{"a la prochaine"}, 30, "a la prochaine"},
+		{"max words", []string{"un deux trois quatre cinq"}, 3, "un deux trois"},
+		{"whitespace is ignored", []string{"  a   la\tprochaine \n"}, 30, "a la prochaine"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(2)
+			for _, text := range tt.texts {
+				c.Train(text)
+			}
+			if got := c.Generate(rand.New(rand.NewSource(42)), tt.maxWords); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateOnlyKnownTransitions(t *testing.T) {
+	c := trained(2)
+	rng := rand.New(rand.NewSource(42))
+	for range 50 {
+		words := strings.Fields(c.Generate(rng, 30))
+		state := []string{"", ""}
+		for _, word := range words {
+			if c.State(strings.Join(state, separator))[word] == 0 {
+				t.Fatalf("%q never follows %q in %q", word, state, words)
+			}
+			state = append(state[1:], word)
+		}
+	}
+}
+
+func TestTrainReturnsChangedStates(t *testing.T) {
+	c := New(2)
+	states := c.Train("a b c")
+	// The padded start, each word and the end
+	if len(states) != 4 {
+		t.Fatalf("got %d states, want 4", len(states))
+	}
+	if got := c.State(states[0]); got["a"] != 1 || len(got) != 1 {
+		t.Errorf("start state is %v, want a:1", got)
+	}
+	if len(c.Train("")) != 0 {
+		t.Error("empty text changed the chain")
+	}
+}
+
+func TestSetStateRoundTrip(t *testing.T) {
+	c := trained(2)
+	loaded := New(2)
+	for _, text := range corpus {
+		for _, state := range New(2).Train(text) {
+			loaded.SetState(state, c.State(state))
+		}
+	}
+	if loaded.Len() != c.Len() {
+		t.Fatalf("got %d states, want %d", loaded.Len(), c.Len())
+	}
+	for seed := int64(1); seed <= 20; seed++ {
+		want := c.Generate(rand.New(rand.NewSource(seed)), 30)
+		if got := loaded.Generate(rand.New(rand.NewSource(seed)), 30); got != want {
+			t.Errorf("seed %d: got %q, want %q", seed, got, want)
+		}
+	}
+}
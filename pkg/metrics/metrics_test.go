@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	r := NewRegistry()
+	events := r.NewCounterVec("events_total", "Events received,\nby type", "type")
+	events.Inc("posted")
+	events.Add(2, "hello")
+	events.Inc("posted")
+	r.NewCounterVec("restarts_total", "Restarts")
+	weird := r.NewCounterVec("weird_total", "Odd label values", "value")
+	weird.Inc(`say "hi"`)
+	weird.Inc(`C:\bot`)
+	weird.Inc("two\nlines")
+
+	depth := r.NewGaugeVec("queue_depth", "Queue depth", "worker", "kind")
+	depth.Set(3, "1", "b")
+	depth.Set(1.5, "0", "z")
+	depth.Add(-1, "1", "b")
+	r.NewGaugeVec("up", "Up")
+
+	latency := r.NewHistogramVec("latency_seconds", "Latency", []float64{0.1, 1}, "api")
+	latency.Observe(0.05, "weather")
+	latency.Observe(0.1, "weather")
+	latency.Observe(0.5, "weather")
+	latency.Observe(3, "weather")
+	latency.Observe(2, "dota")
+	r.NewHistogramVec("empty_seconds", "Nothing observed", DefaultBuckets)
+
+	want := `# HELP events_total Events received, by type
+# TYPE events_total counter
+events_total{type="hello"} 2
+events_total{type="posted"} 2
+# HELP restarts_total Restarts
+# TYPE restarts_total counter
+restarts_total 0
+# HELP weird_total Odd label values
+# TYPE weird_total counter
+weird_total{value="C:\\bot"} 1
+weird_total{value="say \"hi\""} 1
+weird_total{value="two\nlines"} 1
+# HELP queue_depth Queue depth
+# TYPE queue_depth gauge
+queue_depth{worker="0",kind="z"} 1.5
+queue_depth{worker="1",kind="b"} 2
+# HELP up Up
+# TYPE up gauge
+up 0
+# HELP latency_seconds Latency
+# TYPE latency_seconds histogram
+latency_seconds_bucket{api="dota",le="0.1"} 0
+latency_seconds_bucket{api="dota",le="1"} 0
+latency_seconds_bucket{api="dota",le="+Inf"} 1
+latency_seconds_sum{api="dota"} 2
+latency_seconds_count{api="dota"} 1
+latency_seconds_bucket{api="weather",le="0.1"} 2
+latency_seconds_bucket{api="weather",le="1"} 3
+latency_seconds_bucket{api="weather",le="+Inf"} 4
+latency_seconds_sum{api="weather"} 3.65
+latency_seconds_count{api="weather"} 4
+# HELP empty_seconds Nothing observed
+# TYPE empty_seconds histogram
+`
+	var sb strings.Builder
+	if err := r.Write(&sb); err != nil {
+		t.Fatal(err)
+	}
+	if got := sb.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("events_total", "Events").Inc()
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if got := rec.Header().Get("Content-Type"); got != "text/plain; version=0.0.4; charset=utf-8" {
+		t.Errorf("got content type %q", got)
+	}
+	body, _ := io.ReadAll(rec.Body)
+	if want := "# HELP events_total Events\n# TYPE events_total counter\nevents_total 1\n"; string(body) != want {
+		t.Errorf("got %q, want %q", body, want)
+	}
+}
+
+func TestWrongLabelCount(t *testing.T) {
+	r := NewRegistry()
+	counter := r.NewCounterVec("events_total", "Events", "type")
+	gauge := r.NewGaugeVec("queue_depth", "Queue depth", "worker")
+	histogram := r.NewHistogramVec("latency_seconds", "Latency", DefaultBuckets, "api", "status")
+
+	tests := []struct {
+		name string
+		fn   func()
+		want string
+	}{
+		{"counter without labels", func() { counter.Inc() }, "metrics: events_total expects 1 label values, got 0"},
+		{"counter with extra labels", func() { counter.Add(1, "a", "b") }, "metrics: events_total expects 1 label values, got 2"},
+		{"gauge", func() { gauge.Set(1) }, "metrics: queue_depth expects 1 label values, got 0"},
+		{"histogram", func() { histogram.Observe(1, "weather") }, "metrics: latency_seconds expects 2 label values, got 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if got := recover(); got != tt.want {
+					t.Errorf("got panic %v, want %q", got, tt.want)
+				}
+			}()
+			tt.fn()
+		})
+	}
+}
@@ -0,0 +1,50 @@
+// Package normalize turns chat messages into plain text suited to pattern
+// matching
+package normalize
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	fencedCode   = regexp.MustCompile("(?s)(```|~~~).*?(```|~~~|$)")
+	inlineCode   = regexp.MustCompile("`[^`\n]*`")
+	blockquote   = regexp.MustCompile(`(?m)^[ \t]*>.*$`)
+	markdownLink = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+	autolink     = regexp.MustCompile(`<(?:https?|ftp|mailto):[^>]*>`)
+	bareURL      = regexp.MustCompile(`(?i)\b(?:(?:https?|ftp)://|www\.)\S+`)
+)
+
+// Message strips the markup then the diacritics of a message
+func Message(text string) string {
+	return StripDiacritics(StripMarkup(text))
+}
+
+// StripMarkup removes fenced and inline code, quoted lines and URLs. The
+// text of Markdown links is kept.
+func StripMarkup(text string) string {
+	text = fencedCode.ReplaceAllString(text, " ")
+	text = inlineCode.ReplaceAllString(text, " ")
+	text = blockquote.ReplaceAllString(text, "")
+	text = markdownLink.ReplaceAllString(text, "$1")
+	text = autolink.ReplaceAllString(text, " ")
+	text = bareURL.ReplaceAllString(text, " ")
+	return strings.TrimSpace(text)
+}
+
+// StripDiacritics removes accents and other combining marks, e.g. "à tantôt"
+// becomes "a tantot"
+func StripDiacritics(text string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	stripped, _, err := transform.String(t, text)
+	if err != nil {
+		return text
+	}
+	return stripped
+}
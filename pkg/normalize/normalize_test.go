@@ -0,0 +1,40 @@
+package normalize
+
+import "testing"
+
+func TestMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"plain", "bon matin", "bon matin"},
+		{"accents", "vélo en hiver", "velo en hiver"},
+		{"grave and circumflex", "à tantôt", "a tantot"},
+		{"capitals and cedilla", "ÇA VA ÊTRE L'ÉTÉ", "CA VA ETRE L'ETE"},
+		{"decomposed accents", "ve\u0301lo", "velo"},
+		{"ligatures are kept", "cœur", "cœur"},
+		{"emoji are kept", "salut 👋", "salut 👋"},
+		{"fenced code", "avant\n```go\nsalut()\n```\napres", "avant\n \napres"},
+		{"tilde fence", "avant ~~~salut~~~ apres", "avant   apres"},
+		{"unclosed fence", "avant ```salut", "avant"},
+		{"inline code", "lance `a tantot` stp", "lance   stp"},
+		{"inline code ends at the line", "un ` seul\nbacktick", "un ` seul\nbacktick"},
+		{"quote", "> bon matin\nsalut", "salut"},
+		{"indented quote", "  > bon matin", ""},
+		{"quote in a line", "a > b", "a > b"},
+		{"URL", "https://example.com/velo-en-hiver wow", "wow"},
+		{"www URL", "va sur www.velo.ca/hiver", "va sur"},
+		{"autolink", "<https://example.com/salut> wow", "wow"},
+		{"markdown link keeps its text", "[vélo en hiver](https://example.com/a) lol", "velo en hiver lol"},
+		{"image", "![salut](https://example.com/a.png)", "salut"},
+		{"only markup", "`salut`", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Message(tt.text); got != tt.want {
+				t.Errorf("Message(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
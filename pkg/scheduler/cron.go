@@ -0,0 +1,217 @@
+package scheduler
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// maxSearchYears bounds the search for the next run, so schedules that can
+// never match (e.g. February 30th) don't loop forever
+const maxSearchYears = 5
+
+// Schedule is a parsed cron expression. It has the usual 5 fields: minute,
+// hour, day of month, month and day of week.
+type Schedule struct {
+	expr    string
+	minutes []bool
+	hours   []bool
+	days    []bool
+	months  []bool
+	// weekdays is indexed by time.Weekday, Sunday being 0
+	weekdays []bool
+	// As in cron, a day matches either field when both the day of month and
+	// the day of week are restricted
+	daysRestricted     bool
+	weekdaysRestricted bool
+}
+
+// field describes the values allowed in a cron field
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField  = field{name: "minute", min: 0, max: 59}
+	hourField    = field{name: "hour", min: 0, max: 23}
+	dayField     = field{name: "day of month", min: 1, max: 31}
+	monthField   = field{name: "month", min: 1, max: 12, names: map[string]int{"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6, "jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12}}
+	weekdayField = field{name: "day of week", min: 0, max: 7, names: map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}}
+)
+
+// macros are the shorthands accepted instead of the 5 fields
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a cron expression such as "20 16 * * *" or "@daily". Fields
+// accept *, numbers, ranges (1-5), steps (*/15, 0-30/10), lists (1,15) and
+// the English names of months and days of the week (jan, mon).
+func Parse(expr string) (*Schedule, error) {
+	spec := strings.ToLower(strings.TrimSpace(expr))
+	if macro, ok := macros[spec]; ok {
+		spec = macro
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, errors.Errorf("cron expression %q needs 5 fields, got %d", expr, len(fields))
+	}
+
+	s := &Schedule{expr: expr}
+	var err error
+	if s.minutes, err = minuteField.parse(fields[0]); err != nil {
+		return nil, err
+	}
+	if s.hours, err = hourField.parse(fields[1]); err != nil {
+		return nil, err
+	}
+	if s.days, err = dayField.parse(fields[2]); err != nil {
+		return nil, err
+	}
+	if s.months, err = monthField.parse(fields[3]); err != nil {
+		return nil, err
+	}
+	weekdays, err := weekdayField.parse(fields[4])
+	if err != nil {
+		return nil, err
+	}
+	// 7 is Sunday too
+	weekdays[0] = weekdays[0] || weekdays[7]
+	s.weekdays = weekdays[:7]
+
+	s.daysRestricted = !strings.HasPrefix(fields[2], "*")
+	s.weekdaysRestricted = !strings.HasPrefix(fields[4], "*")
+	return s, nil
+}
+
+// String returns the expression the schedule was parsed from
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// parse returns which values of the field a cron field allows
+func (f field) parse(spec string) ([]bool, error) {
+	allowed := make([]bool, f.max+1)
+	for _, part := range strings.Split(spec, ",") {
+		rangeSpec, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n < 1 {
+				return nil, errors.Errorf("invalid step in %s field %q", f.name, spec)
+			}
+			rangeSpec, step = part[:i], n
+		}
+
+		var low, high int
+		switch {
+		case rangeSpec == "*":
+			low, high = f.min, f.max
+		case strings.Contains(rangeSpec, "-"):
+			bounds := strings.SplitN(rangeSpec, "-", 2)
+			var err error
+			if low, err = f.value(bounds[0]); err != nil {
+				return nil, err
+			}
+			if high, err = f.value(bounds[1]); err != nil {
+				return nil, err
+			}
+			if low > high {
+				return nil, errors.Errorf("invalid range in %s field %q", f.name, spec)
+			}
+		default:
+			var err error
+			if low, err = f.value(rangeSpec); err != nil {
+				return nil, err
+			}
+			high = low
+			// As in cron, 5/10 means every 10 starting at 5
+			if step > 1 {
+				high = f.max
+			}
+		}
+
+		for v := low; v <= high; v += step {
+			allowed[v] = true
+		}
+	}
+	return allowed, nil
+}
+
+// value parses a single number or name of the field
+func (f field) value(spec string) (int, error) {
+	if v, ok := f.names[spec]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(spec)
+	if err != nil || v < f.min || v > f.max {
+		return 0, errors.Errorf("invalid %s %q, expected %d-%d", f.name, spec, f.min, f.max)
+	}
+	return v, nil
+}
+
+// Next returns the first time strictly after t matching the schedule, in the
+// time zone of t. It returns the zero time if nothing matches within a few
+// years. Times skipped by a DST change don't match, and the hour repeated
+// when DST ends only matches once.
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = nextMinute(t.Truncate(time.Minute))
+	limit := t.Year() + maxSearchYears
+
+	for t.Year() <= limit {
+		if !s.months[t.Month()] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.hours[t.Hour()] {
+			next := time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			// Leaving the hour repeated by DST would go back in time
+			if !next.After(t) {
+				next = t.Add(time.Hour).Truncate(time.Minute)
+			}
+			t = next
+			continue
+		}
+		if !s.minutes[t.Minute()] {
+			t = nextMinute(t)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// nextMinute returns the start of the next minute, skipping the hour
+// repeated when DST ends
+func nextMinute(t time.Time) time.Time {
+	next := t.Add(time.Minute)
+	if next.Day() == t.Day() && next.Hour()*60+next.Minute() < t.Hour()*60+t.Minute() {
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+	}
+	return next
+}
+
+// dayMatches tells whether the day of t matches the day of month and day of
+// week fields
+func (s *Schedule) dayMatches(t time.Time) bool {
+	day, weekday := s.days[t.Day()], s.weekdays[t.Weekday()]
+	if s.daysRestricted && s.weekdaysRestricted {
+		return day || weekday
+	}
+	return day && weekday
+}
@@ -0,0 +1,95 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{"* * * *", `cron expression "* * * *" needs 5 fields, got 4`},
+		{"@fortnightly", `cron expression "@fortnightly" needs 5 fields, got 1`},
+		{"60 * * * *", `invalid minute "60", expected 0-59`},
+		{"* 24 * * *", `invalid hour "24", expected 0-23`},
+		{"* * 0 * *", `invalid day of month "0", expected 1-31`},
+		{"* * * 13 *", `invalid month "13", expected 1-12`},
+		{"* * * * 8", `invalid day of week "8", expected 0-7`},
+		{"* * * foo *", `invalid month "foo", expected 1-12`},
+		{"* * * * monday", `invalid day of week "monday", expected 0-7`},
+		{"30-10 * * * *", `invalid range in minute field "30-10"`},
+		{"*/0 * * * *", `invalid step in minute field "*/0"`},
+		{"*/x * * * *", `invalid step in minute field "*/x"`},
+		{"1,,2 * * * *", `invalid minute "", expected 0-59`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := Parse(tt.expr)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	montreal, err := time.LoadLocation("America/Montreal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	utc := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want []string
+	}{
+		{"every minute", "* * * * *", utc(2026, 1, 15, 10, 7), []string{"2026-01-15 10:08 UTC", "2026-01-15 10:09 UTC"}},
+		{"strictly after", "0 10 * * *", utc(2026, 1, 15, 10, 0), []string{"2026-01-16 10:00 UTC"}},
+		{"seconds are ignored", "8 10 * * *", utc(2026, 1, 15, 10, 7).Add(59 * time.Second), []string{"2026-01-15 10:08 UTC"}},
+		{"step", "*/15 * * * *", utc(2026, 1, 15, 10, 7), []string{"2026-01-15 10:15 UTC", "2026-01-15 10:30 UTC", "2026-01-15 10:45 UTC", "2026-01-15 11:00 UTC"}},
+		{"range with step", "0 9-17/4 * * *", utc(2026, 1, 15, 10, 0), []string{"2026-01-15 13:00 UTC", "2026-01-15 17:00 UTC", "2026-01-16 09:00 UTC"}},
+		{"start with step", "5/20 * * * *", utc(2026, 1, 15, 10, 0), []string{"2026-01-15 10:05 UTC", "2026-01-15 10:25 UTC", "2026-01-15 10:45 UTC", "2026-01-15 11:05 UTC"}},
+		{"list", "0 8,12 * * *", utc(2026, 1, 15, 10, 0), []string{"2026-01-15 12:00 UTC", "2026-01-16 08:00 UTC"}},
+		{"weekdays by name", "0 7 * * mon-fri", utc(2026, 1, 16, 8, 0), []string{"2026-01-19 07:00 UTC", "2026-01-20 07:00 UTC"}},
+		{"month by name", "0 0 1 jan,JUL *", utc(2026, 1, 15, 0, 0), []string{"2026-07-01 00:00 UTC", "2027-01-01 00:00 UTC"}},
+		{"7 is Sunday", "0 12 * * 7", utc(2026, 1, 15, 0, 0), []string{"2026-01-18 12:00 UTC", "2026-01-25 12:00 UTC"}},
+		{"day of month", "0 0 13 * *", utc(2026, 1, 1, 0, 0), []string{"2026-01-13 00:00 UTC", "2026-02-13 00:00 UTC"}},
+		{"day of month or day of week", "0 0 13 * fri", utc(2026, 1, 1, 0, 0), []string{"2026-01-02 00:00 UTC", "2026-01-09 00:00 UTC", "2026-01-13 00:00 UTC", "2026-01-16 00:00 UTC"}},
+		{"day of month and every day of week", "0 0 13 * */1", utc(2026, 1, 1, 0, 0), []string{"2026-01-13 00:00 UTC", "2026-02-13 00:00 UTC"}},
+		{"day of week and every day of month", "0 0 * * fri", utc(2026, 1, 1, 0, 0), []string{"2026-01-02 00:00 UTC", "2026-01-09 00:00 UTC"}},
+		{"31st skips short months", "0 0 31 * *", utc(2026, 1, 31, 12, 0), []string{"2026-03-31 00:00 UTC", "2026-05-31 00:00 UTC"}},
+		{"February 29th", "0 0 29 2 *", utc(2026, 1, 1, 0, 0), []string{"2028-02-29 00:00 UTC", "2032-02-29 00:00 UTC"}},
+		{"February 30th never comes", "0 0 30 2 *", utc(2026, 1, 1, 0, 0), []string{"0001-01-01 00:00 UTC"}},
+		{"@daily", "@daily", utc(2026, 12, 31, 10, 0), []string{"2027-01-01 00:00 UTC"}},
+		{"@hourly", "@HOURLY", utc(2026, 1, 15, 10, 30), []string{"2026-01-15 11:00 UTC", "2026-01-15 12:00 UTC"}},
+		{"@weekly", "@weekly", utc(2026, 1, 15, 10, 30), []string{"2026-01-18 00:00 UTC"}},
+		{"time zone", "20 16 * * *", time.Date(2026, 1, 15, 17, 0, 0, 0, montreal), []string{"2026-01-16 16:20 EST"}},
+		{"spring forward skips the missing time", "30 2 * * *", time.Date(2026, 3, 7, 3, 0, 0, 0, montreal), []string{"2026-03-09 02:30 EDT", "2026-03-10 02:30 EDT"}},
+		{"spring forward", "0 * * * *", time.Date(2026, 3, 8, 0, 30, 0, 0, montreal), []string{"2026-03-08 01:00 EST", "2026-03-08 03:00 EDT", "2026-03-08 04:00 EDT"}},
+		{"fall back runs once", "30 1 * * *", time.Date(2026, 10, 31, 12, 0, 0, 0, montreal), []string{"2026-11-01 01:30 EDT", "2026-11-02 01:30 EST"}},
+		{"fall back skips the repeated hour", "*/30 * * * *", time.Date(2026, 11, 1, 0, 45, 0, 0, montreal), []string{"2026-11-01 01:00 EDT", "2026-11-01 01:30 EDT", "2026-11-01 02:00 EST", "2026-11-01 02:30 EST"}},
+		{"fall back hour by hour", "0 * * * *", time.Date(2026, 11, 1, 0, 0, 0, 0, montreal), []string{"2026-11-01 01:00 EDT", "2026-11-01 02:00 EST"}},
+		{"from within the repeated hour", "0 2 * * *", time.Date(2026, 11, 1, 6, 15, 0, 0, time.UTC).In(montreal), []string{"2026-11-01 02:00 EST"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			next := tt.from
+			for i, want := range tt.want {
+				next = s.Next(next)
+				if got := next.Format("2006-01-02 15:04 MST"); got != want {
+					t.Fatalf("run %d: got %s, want %s", i+1, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/opendwellers/jujubot/pkg/store"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	// lastRunCollection stores when each job last ran, keyed by job name
+	lastRunCollection = "schedule"
+
+	// DefaultGrace is how late a missed run may still happen, e.g. after a restart
+	DefaultGrace = time.Hour
+	// defaultInterval is how often the scheduler looks for due jobs
+	defaultInterval = 30 * time.Second
+)
+
+// Job runs on a cron schedule
+type Job struct {
+	// Name identifies the job, its last run is stored under it
+	Name     string
+	Schedule *Schedule
+	// Location is the time zone the schedule is evaluated in, UTC when nil
+	Location *time.Location
+	Run      func(ctx context.Context) error
+}
+
+// Scheduler runs jobs when they are due. When each job last ran is kept in
+// the store so a restart neither runs a job twice nor forgets it.
+type Scheduler struct {
+	store store.Store
+	now   func() time.Time
+	jobs  []*Job
+
+	// Grace is how late a missed run may still happen. Runs missed by more,
+	// e.g. while the bot was down, are skipped.
+	Grace time.Duration
+	// Interval is how often Start looks for due jobs
+	Interval time.Duration
+}
+
+// New creates a scheduler reading the time from now
+func New(st store.Store, now func() time.Time) *Scheduler {
+	return &Scheduler{
+		store:    st,
+		now:      now,
+		Grace:    DefaultGrace,
+		Interval: defaultInterval,
+	}
+}
+
+// Add registers a job. Names must be unique.
+func (s *Scheduler) Add(job Job) error {
+	if job.Name == "" || job.Schedule == nil || job.Run == nil {
+		return errors.New("job needs a name, a schedule and a function to run")
+	}
+	for _, other := range s.jobs {
+		if other.Name == job.Name {
+			return errors.New("duplicate job name: " + job.Name)
+		}
+	}
+	if job.Location == nil {
+		job.Location = time.UTC
+	}
+	s.jobs = append(s.jobs, &job)
+	return nil
+}
+
+// Len returns the number of jobs
+func (s *Scheduler) Len() int {
+	return len(s.jobs)
+}
+
+// Start runs due jobs every Interval until ctx is cancelled
+func (s *Scheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	s.RunDue(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.RunDue(ctx)
+		}
+	}
+}
+
+// RunDue runs every job whose next run is due, one after the other. A job
+// seen for the first time only starts counting from now.
+func (s *Scheduler) RunDue(ctx context.Context) {
+	for _, job := range s.jobs {
+		if ctx.Err() != nil {
+			return
+		}
+		due, err := s.claim(job)
+		if err != nil {
+			zap.S().Error("Failed to check job "+job.Name, zap.Error(err))
+			continue
+		}
+		if !due {
+			continue
+		}
+
+		zap.S().Info("Running scheduled job " + job.Name)
+		if err := job.Run(ctx); err != nil {
+			zap.S().Error("Scheduled job "+job.Name+" failed", zap.Error(err))
+		}
+	}
+}
+
+// claim tells whether job should run now, recording the run beforehand so
+// a crash while running doesn't run it again
+func (s *Scheduler) claim(job *Job) (bool, error) {
+	now := s.now().In(job.Location)
+	due := false
+	err := s.store.Update(func(tx store.Tx) error {
+		var lastRun time.Time
+		found, err := tx.Get(lastRunCollection, job.Name, &lastRun)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return tx.Put(lastRunCollection, job.Name, now)
+		}
+
+		next := job.Schedule.Next(lastRun.In(job.Location))
+		if next.IsZero() || next.After(now) {
+			return nil
+		}
+
+		// Only the latest missed run counts, and only if it's recent enough
+		since := now.Add(-s.Grace)
+		if lastRun.After(since) {
+			since = lastRun
+		}
+		if latest := job.Schedule.Next(since.In(job.Location)); !latest.IsZero() && !latest.After(now) {
+			due = true
+		} else {
+			zap.S().Warn("Skipping job "+job.Name+" missed at ", next)
+		}
+		return tx.Put(lastRunCollection, job.Name, now)
+	})
+	return due, err
+}
@@ -0,0 +1,124 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/opendwellers/jujubot/pkg/store"
+	"github.com/pkg/errors"
+)
+
+// testScheduler runs a job at 8:00 every day and counts its runs. Time is
+// read from *now.
+func testScheduler(t *testing.T, st store.Store, now *time.Time, runs *int) *Scheduler {
+	t.Helper()
+	s := New(st, func() time.Time { return *now })
+	schedule, err := Parse("0 8 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.Add(Job{Name: "wotd", Schedule: schedule, Run: func(context.Context) error {
+		*runs++
+		return errors.New("posting failed")
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func at(day, hour, minute int) time.Time {
+	return time.Date(2026, time.January, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestRunDue(t *testing.T) {
+	tests := []struct {
+		name string
+		// steps are the times RunDue is called at, with the runs expected so far
+		steps []time.Time
+		want  []int
+	}{
+		{"first seen doesn't run", []time.Time{at(15, 8, 0)}, []int{0}},
+		{"on time", []time.Time{at(15, 7, 0), at(15, 7, 59), at(15, 8, 0), at(15, 8, 0), at(15, 9, 0)}, []int{0, 0, 1, 1, 1}},
+		{"late within grace", []time.Time{at(15, 7, 0), at(15, 8, 59)}, []int{0, 1}},
+		{"missed beyond grace", []time.Time{at(15, 7, 0), at(15, 9, 1), at(15, 12, 0)}, []int{0, 0, 0}},
+		{"next day after a skipped run", []time.Time{at(15, 7, 0), at(15, 9, 1), at(16, 8, 0)}, []int{0, 0, 1}},
+		{"days missed run once", []time.Time{at(15, 7, 0), at(18, 8, 30), at(18, 8, 31)}, []int{0, 1, 1}},
+		{"days missed beyond grace", []time.Time{at(15, 7, 0), at(18, 10, 0)}, []int{0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var now time.Time
+			runs := 0
+			s := testScheduler(t, store.NewMemory(), &now, &runs)
+			for i, step := range tt.steps {
+				now = step
+				s.RunDue(context.Background())
+				if runs != tt.want[i] {
+					t.Fatalf("at %s: got %d runs, want %d", step.Format(time.DateTime), runs, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRunDueAfterRestart(t *testing.T) {
+	st := store.NewMemory()
+	now := at(15, 7, 0)
+	runs := 0
+	testScheduler(t, st, &now, &runs).RunDue(context.Background())
+	now = at(15, 8, 0)
+	testScheduler(t, st, &now, &runs).RunDue(context.Background())
+	if runs != 1 {
+		t.Fatalf("got %d runs, want 1", runs)
+	}
+
+	// Restarting within the same minute, or later that day, doesn't post again
+	testScheduler(t, st, &now, &runs).RunDue(context.Background())
+	now = at(15, 8, 30)
+	testScheduler(t, st, &now, &runs).RunDue(context.Background())
+	if runs != 1 {
+		t.Errorf("got %d runs after restarting, want 1", runs)
+	}
+
+	now = at(16, 8, 0)
+	testScheduler(t, st, &now, &runs).RunDue(context.Background())
+	if runs != 2 {
+		t.Errorf("got %d runs the next day, want 2", runs)
+	}
+}
+
+func TestRunDueCancelled(t *testing.T) {
+	now := at(15, 7, 0)
+	runs := 0
+	s := testScheduler(t, store.NewMemory(), &now, &runs)
+	s.RunDue(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	now = at(15, 8, 0)
+	s.RunDue(ctx)
+	if runs != 0 {
+		t.Errorf("got %d runs after cancelling, want 0", runs)
+	}
+}
+
+func TestAdd(t *testing.T) {
+	s := New(store.NewMemory(), time.Now)
+	schedule, _ := Parse("@daily")
+	run := func(context.Context) error { return nil }
+
+	if err := s.Add(Job{Name: "a", Schedule: schedule, Run: run}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add(Job{Name: "a", Schedule: schedule, Run: run}); err == nil {
+		t.Error("added a duplicate job")
+	}
+	if err := s.Add(Job{Name: "b", Run: run}); err == nil {
+		t.Error("added a job without schedule")
+	}
+	if s.Len() != 1 {
+		t.Errorf("got %d jobs, want 1", s.Len())
+	}
+}
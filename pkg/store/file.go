@@ -0,0 +1,62 @@
+package store
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// OpenFile opens a store persisted as a single JSON file, creating it if
+// needed. Every committed transaction rewrites the file atomically.
+func OpenFile(path string) (Store, error) {
+	s := &memStore{data: make(data)}
+
+	raw, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, errors.Wrap(err, "failed to create store directory")
+		}
+	case err != nil:
+		return nil, errors.Wrap(err, "failed to read store")
+	default:
+		if err := json.Unmarshal(raw, &s.data); err != nil {
+			return nil, errors.Wrap(err, "failed to decode store "+path)
+		}
+	}
+
+	s.persist = func(d data) error {
+		return writeFileAtomic(path, d)
+	}
+	return s, nil
+}
+
+// writeFileAtomic writes d to a temporary file and renames it over path,
+// so readers never see a partially written file
+func writeFileAtomic(path string, d data) error {
+	raw, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary store file")
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(raw); err != nil {
+		_ = tmp.Close()
+		return errors.Wrap(err, "failed to write store")
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return errors.Wrap(err, "failed to sync store")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "failed to close store")
+	}
+	return errors.Wrap(os.Rename(tmp.Name(), path), "failed to replace store")
+}
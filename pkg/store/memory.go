@@ -0,0 +1,7 @@
+package store
+
+// NewMemory creates a store that only lives in memory, for tests and for
+// running without a data directory
+func NewMemory() Store {
+	return &memStore{data: make(data)}
+}
@@ -0,0 +1,186 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// ErrReadOnly is returned when writing inside View
+var ErrReadOnly = errors.New("store: write in a read-only transaction")
+
+// Store is a small transactional key/value store. Keys live in named
+// collections and values are stored as JSON.
+type Store interface {
+	// View runs fn against a consistent, read-only view of the store
+	View(fn func(tx Tx) error) error
+	// Update runs fn in a read-write transaction. Transactions are serialized,
+	// and changes are only applied (and persisted) if fn returns nil.
+	Update(fn func(tx Tx) error) error
+	Close() error
+}
+
+// Tx gives access to the store inside View and Update
+type Tx interface {
+	// Get decodes the value stored under key into v and reports whether it exists
+	Get(collection, key string, v any) (bool, error)
+	// Put stores v under key. It fails in a read-only transaction.
+	Put(collection, key string, v any) error
+	// Delete removes key. It fails in a read-only transaction.
+	Delete(collection, key string) error
+	// Keys returns the sorted keys of a collection
+	Keys(collection string) []string
+}
+
+// Get reads a single value outside of an explicit transaction
+func Get(s Store, collection, key string, v any) (found bool, err error) {
+	err = s.View(func(tx Tx) error {
+		found, err = tx.Get(collection, key, v)
+		return err
+	})
+	return
+}
+
+// Put writes a single value outside of an explicit transaction
+func Put(s Store, collection, key string, v any) error {
+	return s.Update(func(tx Tx) error {
+		return tx.Put(collection, key, v)
+	})
+}
+
+// Delete removes a single value outside of an explicit transaction
+func Delete(s Store, collection, key string) error {
+	return s.Update(func(tx Tx) error {
+		return tx.Delete(collection, key)
+	})
+}
+
+// data maps collection -> key -> JSON value
+type data map[string]map[string]json.RawMessage
+
+// memStore holds the data in memory and calls persist after every committed
+// transaction. It backs both the memory and the file store.
+type memStore struct {
+	mu      sync.RWMutex
+	data    data
+	persist func(data) error
+}
+
+func (s *memStore) View(fn func(tx Tx) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return fn(&tx{base: s.data})
+}
+
+func (s *memStore) Update(fn func(tx Tx) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := &tx{base: s.data, writable: true, writes: make(map[string]map[string]json.RawMessage)}
+	if err := fn(t); err != nil {
+		return err
+	}
+	if len(t.writes) == 0 {
+		return nil
+	}
+
+	// Copy the touched collections so a failed persist leaves the store unchanged
+	next := make(data, len(s.data)+len(t.writes))
+	for collection, values := range s.data {
+		next[collection] = values
+	}
+	for collection, writes := range t.writes {
+		values := make(map[string]json.RawMessage, len(s.data[collection])+len(writes))
+		for key, value := range s.data[collection] {
+			values[key] = value
+		}
+		for key, value := range writes {
+			if value == nil {
+				delete(values, key)
+			} else {
+				values[key] = value
+			}
+		}
+		next[collection] = values
+	}
+
+	if s.persist != nil {
+		if err := s.persist(next); err != nil {
+			return err
+		}
+	}
+	s.data = next
+	return nil
+}
+
+func (s *memStore) Close() error {
+	return nil
+}
+
+// tx is a transaction over a memStore. Writes are buffered until commit,
+// a nil value marking a deletion.
+type tx struct {
+	base     data
+	writable bool
+	writes   map[string]map[string]json.RawMessage
+}
+
+func (t *tx) lookup(collection, key string) (json.RawMessage, bool) {
+	if value, ok := t.writes[collection][key]; ok {
+		return value, value != nil
+	}
+	value, ok := t.base[collection][key]
+	return value, ok
+}
+
+func (t *tx) Get(collection, key string, v any) (bool, error) {
+	raw, ok := t.lookup(collection, key)
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(raw, v)
+}
+
+func (t *tx) Put(collection, key string, v any) error {
+	if !t.writable {
+		return ErrReadOnly
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	t.write(collection, key, raw)
+	return nil
+}
+
+func (t *tx) Delete(collection, key string) error {
+	if !t.writable {
+		return ErrReadOnly
+	}
+	t.write(collection, key, nil)
+	return nil
+}
+
+func (t *tx) write(collection, key string, raw json.RawMessage) {
+	if t.writes[collection] == nil {
+		t.writes[collection] = make(map[string]json.RawMessage)
+	}
+	t.writes[collection][key] = raw
+}
+
+func (t *tx) Keys(collection string) []string {
+	var keys []string
+	for key := range t.base[collection] {
+		if value, ok := t.writes[collection][key]; !ok || value != nil {
+			keys = append(keys, key)
+		}
+	}
+	for key, value := range t.writes[collection] {
+		if _, ok := t.base[collection][key]; !ok && value != nil {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,199 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// get reads key from s, failing the test on error
+func get(t *testing.T, s Store, collection, key string) (string, bool) {
+	t.Helper()
+	var v string
+	found, err := Get(s, collection, key, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v, found
+}
+
+func TestUpdateRollsBackOnError(t *testing.T) {
+	s := NewMemory()
+	if err := Put(s, "c", "kept", "before"); err != nil {
+		t.Fatal(err)
+	}
+
+	failure := errors.New("boom")
+	err := s.Update(func(tx Tx) error {
+		if err := tx.Put("c", "kept", "after"); err != nil {
+			return err
+		}
+		if err := tx.Put("c", "new", "value"); err != nil {
+			return err
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("got error %v, want %v", err, failure)
+	}
+
+	if v, _ := get(t, s, "c", "kept"); v != "before" {
+		t.Errorf("kept = %q, want the value from before the failed transaction", v)
+	}
+	if _, found := get(t, s, "c", "new"); found {
+		t.Error("the failed transaction's new key was committed")
+	}
+}
+
+func TestViewIsReadOnly(t *testing.T) {
+	s := NewMemory()
+	if err := Put(s, "c", "k", "v"); err != nil {
+		t.Fatal(err)
+	}
+
+	err := s.View(func(tx Tx) error {
+		if err := tx.Put("c", "k", "changed"); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("Put: got %v, want ErrReadOnly", err)
+		}
+		if err := tx.Delete("c", "k"); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("Delete: got %v, want ErrReadOnly", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := get(t, s, "c", "k"); v != "v" {
+		t.Errorf("got %q after a read-only transaction, want v", v)
+	}
+}
+
+func TestPersistFailureLeavesStoreUnchanged(t *testing.T) {
+	failure := errors.New("disk full")
+	s := &memStore{data: make(data)}
+	if err := Put(s, "c", "k", "before"); err != nil {
+		t.Fatal(err)
+	}
+	s.persist = func(data) error { return failure }
+
+	if err := Put(s, "c", "k", "after"); !errors.Is(err, failure) {
+		t.Fatalf("got error %v, want %v", err, failure)
+	}
+	if err := Delete(s, "c", "k"); !errors.Is(err, failure) {
+		t.Fatalf("got error %v, want %v", err, failure)
+	}
+	if v, found := get(t, s, "c", "k"); !found || v != "before" {
+		t.Errorf("got %q (found %t), want the value from before the failed writes", v, found)
+	}
+}
+
+func TestTransactionSeesItsOwnWrites(t *testing.T) {
+	s := NewMemory()
+	err := s.Update(func(tx Tx) error {
+		for _, key := range []string{"b", "d", "a"} {
+			if err := tx.Put("c", key, "old "+key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.Update(func(tx Tx) error {
+		if err := tx.Put("c", "a", "new a"); err != nil {
+			return err
+		}
+		if err := tx.Put("c", "c", "new c"); err != nil {
+			return err
+		}
+		if err := tx.Delete("c", "d"); err != nil {
+			return err
+		}
+
+		var v string
+		if found, err := tx.Get("c", "a", &v); err != nil || !found || v != "new a" {
+			t.Errorf("Get a = %q (found %t, err %v), want new a", v, found, err)
+		}
+		if found, _ := tx.Get("c", "d", &v); found {
+			t.Error("Get d found a key deleted in the transaction")
+		}
+		if keys := tx.Keys("c"); !slices.Equal(keys, []string{"a", "b", "c"}) {
+			t.Errorf("Keys = %q, want [a b c]", keys)
+		}
+		if keys := tx.Keys("missing"); len(keys) != 0 {
+			t.Errorf("Keys of a missing collection = %q, want none", keys)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.View(func(tx Tx) error {
+		if keys := tx.Keys("c"); !slices.Equal(keys, []string{"a", "b", "c"}) {
+			t.Errorf("Keys after commit = %q, want [a b c]", keys)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileStoreReload(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "nested", "state.json")
+
+	s, err := OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Put(s, "charge", "alice", "42"); err != nil {
+		t.Fatal(err)
+	}
+	failure := errors.New("boom")
+	_ = s.Update(func(tx Tx) error {
+		_ = tx.Put("charge", "bob", "1")
+		return failure
+	})
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := get(t, reopened, "charge", "alice"); v != "42" {
+		t.Errorf("alice = %q after reload, want 42", v)
+	}
+	if _, found := get(t, reopened, "charge", "bob"); found {
+		t.Error("a rolled back write was persisted")
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "state.json" {
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		t.Errorf("store directory holds %q, want only state.json", names)
+	}
+}
+
+func TestFileStoreRejectsCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenFile(path); err == nil {
+		t.Error("got no error for a corrupt store file")
+	}
+}